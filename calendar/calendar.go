@@ -0,0 +1,481 @@
+// Package calendar keeps track of community events, answering "mcdowell
+// events" and reminding everyone about them a week and a day ahead. Events
+// can also be imported from iCalendar (.ics) files.
+package calendar
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+	"github.com/willmadison/mcdowell"
+)
+
+// Name is the calendar plugin's name, and its section under "plugins" in the
+// bot's config.
+const Name = "calendar"
+
+// DefaultTimeZone is where event times are given unless configured otherwise.
+const DefaultTimeZone = "America/New_York"
+
+// eventsBucket is where events are stored, keyed by ID.
+const eventsBucket = "events"
+
+// reminderInterval is how often the calendar checks for reminders to send.
+const reminderInterval = time.Minute
+
+// importInterval is how often the configured .ics files are imported again.
+const importInterval = time.Hour
+
+// importHorizon is how far ahead recurring events are imported.
+const importHorizon = 180 * 24 * time.Hour
+
+// digestAhead is how far ahead the newsletter looks for events.
+const digestAhead = 31 * 24 * time.Hour
+
+// maxListed is how many upcoming events "events" lists.
+const maxListed = 10
+
+// Reminders go out this long before an event.
+const (
+	weekAhead = 7 * 24 * time.Hour
+	dayAhead  = 24 * time.Hour
+)
+
+// dateLayouts are the ways admins can give an event's date and time.
+var dateLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02 3:04pm",
+	"2006-01-02 3pm",
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+type (
+	// Config is the calendar's section of the bot's config. Reminders are
+	// only posted when there's a Channel to post them in. Import lists .ics
+	// files on disk to keep the calendar in sync with.
+	Config struct {
+		Channel  string   `json:"channel,omitempty"`
+		TimeZone string   `json:"time_zone,omitempty"`
+		Import   []string `json:"import,omitempty"`
+	}
+
+	// Event is something happening in the community.
+	Event struct {
+		ID    string    `json:"id"`
+		UID   string    `json:"uid,omitempty"`
+		Title string    `json:"title"`
+		Start time.Time `json:"start"`
+		Venue string    `json:"venue,omitempty"`
+		Link  string    `json:"link,omitempty"`
+
+		RemindedWeek bool `json:"reminded_week,omitempty"`
+		RemindedDay  bool `json:"reminded_day,omitempty"`
+	}
+
+	// Calendar is the calendar plugin.
+	Calendar struct {
+		mu       sync.Mutex
+		channel  string
+		location *time.Location
+	}
+)
+
+// New creates the calendar plugin.
+func New() *Calendar {
+	return &Calendar{}
+}
+
+// Name implements mcdowell.Plugin.
+func (c *Calendar) Name() string {
+	return Name
+}
+
+// Init implements mcdowell.Plugin.
+func (c *Calendar) Init(b *mcdowell.Bot) error {
+	var config Config
+
+	err := b.PluginConfig(Name, &config)
+	if err != nil && err != mcdowell.ErrNotFound {
+		return err
+	}
+
+	if config.TimeZone == "" {
+		config.TimeZone = DefaultTimeZone
+	}
+
+	c.location, err = time.LoadLocation(config.TimeZone)
+	if err != nil {
+		return errors.Wrapf(err, "unknown time zone %q", config.TimeZone)
+	}
+
+	if config.Channel != "" {
+		c.channel, err = b.ResolveChannel(config.Channel)
+		if err != nil {
+			return errors.Wrap(err, "unable to resolve the events channel")
+		}
+
+		go c.watch(b)
+	} else {
+		log.Println("no events channel configured, event reminders are disabled")
+	}
+
+	if len(config.Import) > 0 {
+		c.importFiles(b, config.Import)
+
+		go c.watchImports(b, config.Import)
+	}
+
+	err = b.RegisterDigestSection(mcdowell.DigestSection{Title: "Coming up", Render: c.comingUp})
+	if err != nil {
+		return err
+	}
+
+	return b.RegisterCommand(mcdowell.Command{
+		Name:        "events",
+		Usage:       `events [add "title" YYYY-MM-DD HH:MM "venue" link | remove id]`,
+		Description: "Lists upcoming community events. Admins can add and remove them.",
+		Handler:     c.command,
+	})
+}
+
+// Handlers implements mcdowell.Plugin.
+func (c *Calendar) Handlers() map[string]mcdowell.Handler {
+	return map[string]mcdowell.Handler{
+		mcdowell.EventMessage: c.onMessage,
+	}
+}
+
+// Location returns the time zone event times are given in.
+func (c *Calendar) Location() *time.Location {
+	return c.location
+}
+
+func (c *Calendar) command(b *mcdowell.Bot, req *mcdowell.CommandRequest) (string, error) {
+	if len(req.Args) == 0 {
+		return c.upcoming(b, b.IsAdmin(req.UserID))
+	}
+
+	subcommand := strings.ToLower(req.Args[0])
+
+	switch subcommand {
+	case "add", "remove":
+		if !b.IsAdmin(req.UserID) {
+			return fmt.Sprintf("Sorry, only admins can %s events.", subcommand), nil
+		}
+	}
+
+	switch subcommand {
+	case "add":
+		return c.add(b, req.Args[1:])
+	case "remove":
+		return c.remove(b, req.Args[1:])
+	default:
+		return "Sorry, I don't know how to do that with events. Try `help events`.", nil
+	}
+}
+
+func (c *Calendar) upcoming(b *mcdowell.Bot, showIDs bool) (string, error) {
+	events, err := Events(b.Store())
+	if err != nil {
+		return "", err
+	}
+
+	now := b.Now()
+
+	var text strings.Builder
+
+	var listed int
+	for _, e := range events {
+		if e.Start.Before(now) || listed == maxListed {
+			continue
+		}
+
+		if listed == 0 {
+			text.WriteString("*Upcoming events*\n")
+		}
+
+		listed++
+
+		fmt.Fprintf(&text, "• %s", c.describe(e))
+
+		if showIDs {
+			fmt.Fprintf(&text, " `%s`", e.ID)
+		}
+
+		text.WriteString("\n")
+	}
+
+	if listed == 0 {
+		return "No upcoming events. Check back soon!", nil
+	}
+
+	return strings.TrimSuffix(text.String(), "\n"), nil
+}
+
+// comingUp lists the next month's events for the newsletter.
+func (c *Calendar) comingUp(b *mcdowell.Bot, since time.Time) (string, error) {
+	events, err := Events(b.Store())
+	if err != nil {
+		return "", err
+	}
+
+	now := b.Now()
+
+	var text strings.Builder
+
+	for _, e := range events {
+		if e.Start.Before(now) || e.Start.Sub(now) > digestAhead {
+			continue
+		}
+
+		fmt.Fprintf(&text, "• %s\n", c.describe(e))
+	}
+
+	return strings.TrimSuffix(text.String(), "\n"), nil
+}
+
+// describe formats e for a message, e.g. "*Tech Happy Hour* - Saturday,
+// June 29 at 6:30 PM at Ponce City Market (<link|details>)".
+func (c *Calendar) describe(e Event) string {
+	text := fmt.Sprintf("*%s* - %s", e.Title, e.Start.In(c.location).Format("Monday, January 2 at 3:04 PM"))
+
+	if e.Venue != "" {
+		text += " at " + e.Venue
+	}
+
+	if e.Link != "" {
+		text += " (<" + e.Link + "|details>)"
+	}
+
+	return text
+}
+
+func (c *Calendar) add(b *mcdowell.Bot, args []string) (string, error) {
+	if len(args) < 3 {
+		return `Usage: events add "title" YYYY-MM-DD HH:MM ["venue"] [link]`, nil
+	}
+
+	start, err := c.parseTime(args[1] + " " + args[2])
+	if err != nil {
+		return fmt.Sprintf("Sorry, I couldn't make sense of %q. Dates look like 2019-06-29 18:30.", args[1]+" "+args[2]), nil
+	}
+
+	e := Event{Title: args[0], Start: start}
+
+	if len(args) > 3 {
+		e.Venue = args[3]
+	}
+
+	if len(args) > 4 {
+		e.Link = unwrapLink(args[4])
+	}
+
+	if err := c.Upsert(b, e); err != nil {
+		return "", err
+	}
+
+	return "Added " + c.describe(e) + ".", nil
+}
+
+func (c *Calendar) remove(b *mcdowell.Bot, args []string) (string, error) {
+	if len(args) != 1 {
+		return "Usage: events remove id", nil
+	}
+
+	if _, err := b.Store().Get(eventsBucket, args[0]); err == mcdowell.ErrNotFound {
+		return fmt.Sprintf("Sorry, there's no event `%s`.", args[0]), nil
+	}
+
+	if err := b.Store().Delete(eventsBucket, args[0]); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return fmt.Sprintf("Removed `%s`.", args[0]), nil
+}
+
+func (c *Calendar) parseTime(value string) (time.Time, error) {
+	value = strings.ToLower(value)
+
+	var err error
+	for _, layout := range dateLayouts {
+		var t time.Time
+
+		t, err = time.ParseInLocation(layout, value, c.location)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.WithStack(err)
+}
+
+// unwrapLink turns Slack's "<https://example.com|example.com>" back into a plain URL.
+func unwrapLink(link string) string {
+	link = strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">")
+
+	if i := strings.Index(link, "|"); i >= 0 {
+		link = link[:i]
+	}
+
+	return link
+}
+
+// eventID derives a readable ID for e from its date and title, e.g.
+// "2019-06-29-tech-happy-hour".
+func eventID(e Event) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(e.Title), "-"), "-")
+
+	return e.Start.Format("2006-01-02") + "-" + slug
+}
+
+// Upsert adds e to the calendar, replacing the event with the same ID. Events
+// without an ID get one from their date and title. Reminders already sent
+// for an event aren't sent again unless it moves.
+func (c *Calendar) Upsert(b *mcdowell.Bot, e Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.upsert(b, e)
+}
+
+func (c *Calendar) upsert(b *mcdowell.Bot, e Event) error {
+	if e.ID == "" {
+		e.ID = eventID(e)
+	}
+
+	existing, err := get(b.Store(), e.ID)
+	if err == nil && existing.Start.Equal(e.Start) {
+		e.RemindedWeek = existing.RemindedWeek
+		e.RemindedDay = existing.RemindedDay
+	} else if err != nil && err != mcdowell.ErrNotFound {
+		return err
+	}
+
+	return put(b.Store(), e)
+}
+
+// Events returns every event in store, soonest first.
+func Events(store mcdowell.Store) ([]Event, error) {
+	ids, err := store.Keys(eventsBucket)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var events []Event
+
+	for _, id := range ids {
+		e, err := get(store, id)
+		if err == mcdowell.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+
+	return events, nil
+}
+
+func get(store mcdowell.Store, id string) (Event, error) {
+	var e Event
+
+	value, err := store.Get(eventsBucket, id)
+	if err != nil {
+		return e, err
+	}
+
+	return e, errors.Wrapf(json.Unmarshal(value, &e), "corrupt event %s", id)
+}
+
+func put(store mcdowell.Store, e Event) error {
+	value, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return store.Put(eventsBucket, e.ID, value)
+}
+
+// SendReminders posts reminders in the events channel for events a week or
+// a day away. Each reminder is only sent once.
+func (c *Calendar) SendReminders(b *mcdowell.Bot) error {
+	if c.channel == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	events, err := Events(b.Store())
+	if err != nil {
+		return err
+	}
+
+	now := b.Now()
+
+	for _, e := range events {
+		until := e.Start.Sub(now)
+
+		var heading string
+
+		switch {
+		case until <= 0:
+			continue
+		case until <= dayAhead && !e.RemindedDay:
+			heading = "Tomorrow!"
+			e.RemindedDay, e.RemindedWeek = true, true
+		case until <= weekAhead && until > dayAhead && !e.RemindedWeek:
+			heading = "Save the date!"
+			e.RemindedWeek = true
+		default:
+			continue
+		}
+
+		_, _, err := b.Client().PostMessage(c.channel,
+			slack.MsgOptionAsUser(true),
+			slack.MsgOptionText(heading+" "+c.describe(e), false),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "unable to remind everyone about %s", e.ID)
+		}
+
+		if err := put(b.Store(), e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// watch sends reminders as they come due until the bot shuts down.
+func (c *Calendar) watch(b *mcdowell.Bot) {
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.Context().Done():
+			return
+		case <-ticker.C:
+			if err := c.SendReminders(b); err != nil {
+				log.Println("failed to send event reminders:", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package calendar_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/calendar"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+const admin = slacktest.Admin
+
+func newCalendarBot(t *testing.T, config string) (*mcdowell.Bot, *calendar.Calendar, *slacktest.Server, *slacktest.Clock) {
+	t.Helper()
+
+	cal := calendar.New()
+
+	b, slack, c := slacktest.NewBot(t, config, map[string]string{
+		"conversations.list": `{"ok": true, "channels": [{"id": "C0000EVENTS", "name": "events"}]}`,
+		"files/meetups.ics":  meetups,
+		"files/hourly.ics":   hourly,
+	}, mcdowell.WithPlugin(cal))
+
+	return b, cal, slack, c
+}
+
+func TestAddingAndListingEvents(t *testing.T) {
+	b, _, _, _ := newCalendarBot(t, `{}`)
+
+	assert.Equal(t, "No upcoming events. Check back soon!", slacktest.Run(t, b, "U0AKEEM", "events"))
+
+	assert.Equal(t, "Sorry, only admins can add events.", slacktest.Run(t, b, "U0AKEEM", "events", "add", "Party", "2019-06-29", "18:30"))
+
+	assert.Equal(t, "Added *Tech Happy Hour* - Saturday, June 29 at 6:30 PM at Ponce City Market (<https://meetup.com/atlblacktech|details>).",
+		slacktest.Run(t, b, admin, "events", "add", "Tech Happy Hour", "2019-06-29", "18:30", "Ponce City Market", "<https://meetup.com/atlblacktech>"))
+	slacktest.Run(t, b, admin, "events", "add", "Founders Breakfast", "2019-06-15", "8am")
+	slacktest.Run(t, b, admin, "events", "add", "Last Year's Party", "2018-06-29", "18:30")
+
+	assert.Equal(t, `*Upcoming events*
+• *Founders Breakfast* - Saturday, June 15 at 8:00 AM
+• *Tech Happy Hour* - Saturday, June 29 at 6:30 PM at Ponce City Market (<https://meetup.com/atlblacktech|details>)`, slacktest.Run(t, b, "U0AKEEM", "events"))
+
+	assert.Contains(t, slacktest.Run(t, b, admin, "events"), "• *Founders Breakfast* - Saturday, June 15 at 8:00 AM `2019-06-15-founders-breakfast`")
+
+	assert.Equal(t, "Sorry, only admins can remove events.", slacktest.Run(t, b, "U0AKEEM", "events", "remove", "2019-06-15-founders-breakfast"))
+	assert.Equal(t, "Removed `2019-06-15-founders-breakfast`.", slacktest.Run(t, b, admin, "events", "remove", "2019-06-15-founders-breakfast"))
+	assert.Equal(t, "Sorry, there's no event `2019-06-15-founders-breakfast`.", slacktest.Run(t, b, admin, "events", "remove", "2019-06-15-founders-breakfast"))
+
+	assert.NotContains(t, slacktest.Run(t, b, "U0AKEEM", "events"), "Founders Breakfast")
+}
+
+func TestInvalidEventsAreRejected(t *testing.T) {
+	b, _, _, _ := newCalendarBot(t, `{}`)
+
+	assert.Contains(t, slacktest.Run(t, b, admin, "events", "add", "Party"), "Usage:")
+	assert.Contains(t, slacktest.Run(t, b, admin, "events", "add", "Party", "June 29th", "18:30"), "Sorry, I couldn't make sense of")
+	assert.Contains(t, slacktest.Run(t, b, admin, "events", "dance"), "Sorry, I don't know how to do that with events.")
+}
+
+func TestEventReminders(t *testing.T) {
+	b, cal, slack, c := newCalendarBot(t, `{"plugins": {"calendar": {"channel": "#events", "time_zone": "America/New_York"}}}`)
+
+	slacktest.Run(t, b, admin, "events", "add", "Tech Happy Hour", "2019-06-29", "18:30", "Ponce City Market")
+
+	remind := func(now time.Time) []string {
+		c.Set(now)
+		slack.Reset()
+
+		assert.Nil(t, cal.SendReminders(b))
+
+		return slack.Messages()
+	}
+
+	start := time.Date(2019, time.June, 29, 22, 30, 0, 0, time.UTC)
+
+	assert.Empty(t, remind(start.Add(-8*24*time.Hour)))
+	assert.Equal(t, []string{"Save the date! *Tech Happy Hour* - Saturday, June 29 at 6:30 PM at Ponce City Market"}, remind(start.Add(-7*24*time.Hour)))
+	assert.Empty(t, remind(start.Add(-3*24*time.Hour)))
+	assert.Equal(t, []string{"Tomorrow! *Tech Happy Hour* - Saturday, June 29 at 6:30 PM at Ponce City Market"}, remind(start.Add(-23*time.Hour)))
+	assert.Empty(t, remind(start.Add(-time.Hour)))
+	assert.Empty(t, remind(start.Add(time.Hour)))
+}
+
+func TestRescheduledEventsAreRemindedAgain(t *testing.T) {
+	b, cal, slack, c := newCalendarBot(t, `{"plugins": {"calendar": {"channel": "#events"}}}`)
+
+	slacktest.Run(t, b, admin, "events", "add", "Tech Happy Hour", "2019-06-29", "18:30")
+
+	c.Set(time.Date(2019, time.June, 28, 23, 0, 0, 0, time.UTC))
+	assert.Nil(t, cal.SendReminders(b))
+	assert.Len(t, slack.Calls("chat.postMessage"), 1)
+	assert.Equal(t, "C0000EVENTS", slack.Calls("chat.postMessage")[0].Form.Get("channel"))
+
+	err := cal.Upsert(b, calendar.Event{ID: "2019-06-29-tech-happy-hour", Title: "Tech Happy Hour", Start: time.Date(2019, time.June, 29, 23, 30, 0, 0, time.UTC)})
+	assert.Nil(t, err)
+
+	assert.Nil(t, cal.SendReminders(b))
+	assert.Len(t, slack.Calls("chat.postMessage"), 2)
+}
+
+func TestUnknownTimeZonesAreRejected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slack := slacktest.NewServer(t, nil)
+
+	parsed, err := mcdowell.ParseConfig(strings.NewReader(`{"plugins": {"calendar": {"time_zone": "Zamunda/Royal_Palace"}}}`))
+	assert.Nil(t, err)
+
+	_, err = mcdowell.NewBot(ctx, slack.Client(), mcdowell.WithTesting(), mcdowell.WithConfig(parsed), mcdowell.WithPlugin(calendar.New()))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,942 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+	"github.com/willmadison/mcdowell"
+)
+
+// maxOccurrences caps how many times a single recurring event is imported.
+const maxOccurrences = 500
+
+// maxPeriods caps how many days, weeks, months or years a recurring event is
+// followed for, so rules that never match again don't run forever.
+const maxPeriods = 100000
+
+// windowsZones maps the Windows time zone names Outlook and Exchange use as
+// TZIDs to IANA time zones, for the zones members are likely to be in.
+var windowsZones = map[string]string{
+	"Eastern Standard Time":           "America/New_York",
+	"Central Standard Time":           "America/Chicago",
+	"Mountain Standard Time":          "America/Denver",
+	"US Mountain Standard Time":       "America/Phoenix",
+	"Pacific Standard Time":           "America/Los_Angeles",
+	"Alaskan Standard Time":           "America/Anchorage",
+	"Hawaiian Standard Time":          "Pacific/Honolulu",
+	"Atlantic Standard Time":          "America/Halifax",
+	"SA Pacific Standard Time":        "America/Bogota",
+	"E. South America Standard Time":  "America/Sao_Paulo",
+	"GMT Standard Time":               "Europe/London",
+	"W. Europe Standard Time":         "Europe/Berlin",
+	"Romance Standard Time":           "Europe/Paris",
+	"Central Europe Standard Time":    "Europe/Budapest",
+	"W. Central Africa Standard Time": "Africa/Lagos",
+	"South Africa Standard Time":      "Africa/Johannesburg",
+	"E. Africa Standard Time":         "Africa/Nairobi",
+	"India Standard Time":             "Asia/Kolkata",
+	"China Standard Time":             "Asia/Shanghai",
+	"Tokyo Standard Time":             "Asia/Tokyo",
+	"AUS Eastern Standard Time":       "Australia/Sydney",
+	"UTC":                             "UTC",
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+type (
+	// property is a single content line of an iCalendar file, e.g.
+	// "DTSTART;TZID=America/New_York:20190629T183000".
+	property struct {
+		name   string
+		params map[string]string
+		value  string
+	}
+
+	// vevent is the parts of a VEVENT the calendar cares about.
+	vevent struct {
+		uid          string
+		summary      string
+		location     string
+		url          string
+		start        time.Time
+		rrule        string
+		exdates      []time.Time
+		recurrenceID time.Time
+		cancelled    bool
+	}
+
+	// rrule is a parsed RRULE, limited to the parts meetup schedules use.
+	rrule struct {
+		freq       string
+		interval   int
+		count      int
+		until      time.Time
+		byDay      []byDay
+		byMonthDay []int
+		byMonth    []time.Month
+		bySetPos   []int
+	}
+
+	// byDay is a BYDAY entry like "TU" (every Tuesday) or "-1FR" (the last Friday).
+	byDay struct {
+		ordinal int
+		weekday time.Weekday
+	}
+
+	// parsedICS is what's in an iCalendar file.
+	parsedICS struct {
+		events []Event
+		// cancelled are the UIDs of cancelled events, and unexpanded the
+		// titles of recurring events whose rules can't be followed, which
+		// are only included at their start.
+		cancelled  []string
+		unexpanded []string
+	}
+
+	// Imported is what an iCalendar import brought in.
+	Imported struct {
+		Events int
+
+		// Unexpanded are the titles of recurring events whose rules the
+		// calendar can't follow, imported just once, at their start.
+		Unexpanded []string
+	}
+)
+
+// ParseICS reads the VEVENTs in an RFC 5545 iCalendar file, expanding
+// recurring ones into each occurrence between from and until. Times without
+// a time zone, or in one that isn't known, are taken to be in location.
+// Cancelled events and occurrences are left out, and recurring events whose
+// rules can't be followed are only included at their start.
+func ParseICS(r io.Reader, location *time.Location, from, until time.Time) ([]Event, error) {
+	parsed, err := parseICS(r, location, from, until)
+	return parsed.events, err
+}
+
+// parseICS is ParseICS, also returning which events were cancelled and which
+// couldn't be expanded.
+func parseICS(r io.Reader, location *time.Location, from, until time.Time) (parsedICS, error) {
+	var parsed parsedICS
+
+	properties, err := readProperties(r)
+	if err != nil {
+		return parsed, err
+	}
+
+	var (
+		masters   []vevent
+		overrides []vevent
+		current   *vevent
+		// nested counts the components, e.g. VALARMs, open inside the
+		// current VEVENT, whose properties aren't the event's.
+		nested int
+	)
+
+	for _, p := range properties {
+		switch {
+		case p.name == "BEGIN" && p.value == "VEVENT" && current == nil:
+			current, nested = &vevent{}, 0
+		case current == nil:
+			continue
+		case p.name == "BEGIN":
+			nested++
+		case p.name == "END" && nested > 0:
+			nested--
+		case nested > 0:
+			continue
+		case p.name == "END" && p.value == "VEVENT":
+			if current.start.IsZero() {
+				log.Printf("skipping event %q without a start\n", current.summary)
+			} else if current.recurrenceID.IsZero() {
+				masters = append(masters, *current)
+			} else {
+				overrides = append(overrides, *current)
+			}
+
+			current = nil
+		default:
+			if err := current.set(p, location); err != nil {
+				return parsed, errors.Wrapf(err, "invalid %s", p.name)
+			}
+		}
+	}
+
+	overridden := map[string]bool{}
+	for _, o := range overrides {
+		overridden[o.uid+o.recurrenceID.UTC().Format(time.RFC3339)] = true
+	}
+
+	for _, v := range masters {
+		if v.cancelled {
+			if v.uid != "" {
+				parsed.cancelled = append(parsed.cancelled, v.uid)
+			}
+
+			continue
+		}
+
+		starts, err := v.occurrences(from, until)
+		if err != nil {
+			log.Printf("only importing the first occurrence of %q: %v\n", v.summary, err)
+			parsed.unexpanded = append(parsed.unexpanded, v.summary)
+
+			v.rrule = ""
+			starts, _ = v.occurrences(from, until)
+		}
+
+		for _, start := range starts {
+			if overridden[v.uid+start.UTC().Format(time.RFC3339)] {
+				continue
+			}
+
+			parsed.events = append(parsed.events, v.event(start))
+		}
+	}
+
+	for _, o := range overrides {
+		if !o.cancelled && !o.start.Before(from) && o.start.Before(until) {
+			parsed.events = append(parsed.events, o.event(o.start))
+		}
+	}
+
+	sort.SliceStable(parsed.events, func(i, j int) bool {
+		return parsed.events[i].Start.Before(parsed.events[j].Start)
+	})
+
+	return parsed, nil
+}
+
+// readProperties unfolds and splits the content lines in r.
+func readProperties(r io.Reader) ([]property, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		lines []string
+		line  strings.Builder
+	)
+
+	for scanner.Scan() {
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") {
+			line.WriteString(text[1:])
+			continue
+		}
+
+		if line.Len() > 0 {
+			lines = append(lines, line.String())
+		}
+
+		line.Reset()
+		line.WriteString(text)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+
+	if len(lines) == 0 || lines[0] != "BEGIN:VCALENDAR" {
+		return nil, errors.New("not an iCalendar file")
+	}
+
+	properties := make([]property, 0, len(lines))
+
+	for _, line := range lines {
+		p, err := parseProperty(line)
+		if err != nil {
+			return nil, err
+		}
+
+		properties = append(properties, p)
+	}
+
+	return properties, nil
+}
+
+// parseProperty splits a content line into its name, parameters and value,
+// minding quoted parameter values that may contain ':' or ';'.
+func parseProperty(line string) (property, error) {
+	p := property{params: map[string]string{}}
+
+	var quoted bool
+
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+
+	if colon < 0 {
+		return p, errors.Errorf("malformed line %q", line)
+	}
+
+	p.value = line[colon+1:]
+
+	parts := strings.Split(line[:colon], ";")
+	p.name = strings.ToUpper(parts[0])
+
+	for _, param := range parts[1:] {
+		if i := strings.Index(param, "="); i >= 0 {
+			p.params[strings.ToUpper(param[:i])] = strings.Trim(param[i+1:], `"`)
+		}
+	}
+
+	return p, nil
+}
+
+// set records p on v.
+func (v *vevent) set(p property, location *time.Location) error {
+	var err error
+
+	switch p.name {
+	case "UID":
+		v.uid = p.value
+	case "SUMMARY":
+		v.summary = unescapeText(p.value)
+	case "LOCATION":
+		v.location = unescapeText(p.value)
+	case "URL":
+		v.url = p.value
+	case "STATUS":
+		v.cancelled = strings.EqualFold(p.value, "CANCELLED")
+	case "RRULE":
+		v.rrule = p.value
+	case "DTSTART":
+		v.start, err = parseDateTime(p, p.value, location)
+	case "RECURRENCE-ID":
+		v.recurrenceID, err = parseDateTime(p, p.value, location)
+	case "EXDATE":
+		for _, value := range strings.Split(p.value, ",") {
+			exdate, err := parseDateTime(p, value, location)
+			if err != nil {
+				return err
+			}
+
+			v.exdates = append(v.exdates, exdate)
+		}
+	}
+
+	return err
+}
+
+// unescapeText undoes the escaping of TEXT values.
+func unescapeText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+// parseDateTime parses a DATE or DATE-TIME value, using p's TZID parameter
+// if it has one.
+func parseDateTime(p property, value string, location *time.Location) (time.Time, error) {
+	if tzid := p.params["TZID"]; tzid != "" {
+		if zone, ok := windowsZones[tzid]; ok {
+			tzid = zone
+		}
+
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			location = tz
+		} else {
+			log.Printf("unknown time zone %q, using %s\n", tzid, location)
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, errors.WithStack(err)
+	case p.params["VALUE"] == "DATE" || len(value) == len("20060102"):
+		t, err := time.ParseInLocation("20060102", value, location)
+		return t, errors.WithStack(err)
+	default:
+		t, err := time.ParseInLocation("20060102T150405", value, location)
+		return t, errors.WithStack(err)
+	}
+}
+
+// event returns the calendar event for the occurrence of v at start.
+func (v vevent) event(start time.Time) Event {
+	e := Event{
+		UID:   v.uid,
+		Title: v.summary,
+		Start: start,
+		Venue: v.location,
+		Link:  v.url,
+	}
+
+	e.ID = eventID(e)
+
+	return e
+}
+
+// occurrences returns when v happens between from and until.
+func (v vevent) occurrences(from, until time.Time) ([]time.Time, error) {
+	if v.rrule == "" {
+		if v.start.Before(from) || !v.start.Before(until) {
+			return nil, nil
+		}
+
+		return []time.Time{v.start}, nil
+	}
+
+	rule, err := parseRRule(v.rrule, v.start.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := map[int64]bool{}
+	for _, exdate := range v.exdates {
+		excluded[exdate.Unix()] = true
+	}
+
+	var (
+		starts []time.Time
+		seen   int
+	)
+
+	for period := 0; period < maxPeriods && len(starts) < maxOccurrences; period++ {
+		for _, start := range rule.candidates(v.start, period) {
+			if start.Before(v.start) {
+				continue
+			}
+
+			if !rule.until.IsZero() && start.After(rule.until) {
+				return starts, nil
+			}
+
+			if !start.Before(until) {
+				return starts, nil
+			}
+
+			seen++
+
+			if rule.count > 0 && seen > rule.count {
+				return starts, nil
+			}
+
+			if !start.Before(from) && !excluded[start.Unix()] {
+				starts = append(starts, start)
+			}
+		}
+	}
+
+	return starts, nil
+}
+
+func parseRRule(value string, location *time.Location) (rrule, error) {
+	rule := rrule{interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		i := strings.Index(part, "=")
+		if i < 0 {
+			return rule, errors.Errorf("malformed RRULE part %q", part)
+		}
+
+		name, value := strings.ToUpper(part[:i]), part[i+1:]
+
+		var err error
+
+		switch name {
+		case "FREQ":
+			rule.freq = strings.ToUpper(value)
+		case "INTERVAL":
+			rule.interval, err = strconv.Atoi(value)
+		case "COUNT":
+			rule.count, err = strconv.Atoi(value)
+		case "UNTIL":
+			rule.until, err = parseDateTime(property{params: map[string]string{}}, value, location)
+		case "WKST":
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				d, dayErr := parseByDay(day)
+				if dayErr != nil {
+					return rule, dayErr
+				}
+
+				rule.byDay = append(rule.byDay, d)
+			}
+		case "BYMONTHDAY":
+			rule.byMonthDay, err = parseNumbers(value, 31)
+		case "BYMONTH":
+			var months []int
+
+			months, err = parseNumbers(value, 12)
+			for _, m := range months {
+				if m < 1 {
+					return rule, errors.Errorf("invalid BYMONTH %d", m)
+				}
+
+				rule.byMonth = append(rule.byMonth, time.Month(m))
+			}
+		case "BYSETPOS":
+			rule.bySetPos, err = parseNumbers(value, 366)
+		default:
+			return rule, errors.Errorf("unsupported RRULE part %s", name)
+		}
+
+		if err != nil {
+			return rule, errors.Wrapf(err, "invalid RRULE %s", name)
+		}
+	}
+
+	switch rule.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return rule, errors.Errorf("unsupported RRULE frequency %q", rule.freq)
+	}
+
+	if rule.interval < 1 {
+		return rule, errors.Errorf("invalid RRULE interval %d", rule.interval)
+	}
+
+	if rule.freq == "YEARLY" && len(rule.byMonth) == 0 && (len(rule.byDay) > 0 || len(rule.byMonthDay) > 0) {
+		return rule, errors.New("unsupported yearly RRULE without BYMONTH")
+	}
+
+	return rule, nil
+}
+
+// parseNumbers parses a list like "1,15,-1" of non-zero numbers no bigger
+// than max either way.
+func parseNumbers(value string, max int) ([]int, error) {
+	var numbers []int
+
+	for _, v := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if n == 0 || n > max || n < -max {
+			return nil, errors.Errorf("%d is out of range", n)
+		}
+
+		numbers = append(numbers, n)
+	}
+
+	return numbers, nil
+}
+
+func parseByDay(value string) (byDay, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+
+	if len(value) < 2 {
+		return byDay{}, errors.Errorf("invalid BYDAY %q", value)
+	}
+
+	weekday, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return byDay{}, errors.Errorf("invalid BYDAY %q", value)
+	}
+
+	d := byDay{weekday: weekday}
+
+	if ordinal := value[:len(value)-2]; ordinal != "" {
+		n, err := strconv.Atoi(ordinal)
+		if err != nil {
+			return byDay{}, errors.Errorf("invalid BYDAY %q", value)
+		}
+
+		d.ordinal = n
+	}
+
+	return d, nil
+}
+
+// candidates returns the occurrences of the rule in its period-th period
+// (day, week, month or year) after start's, in order.
+func (r rrule) candidates(start time.Time, period int) []time.Time {
+	n := period * r.interval
+	year, month, day := start.Date()
+	hour, min, sec := start.Clock()
+	location := start.Location()
+
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, location)
+	}
+
+	var starts []time.Time
+
+	switch r.freq {
+	case "DAILY":
+		if t := at(year, month, day+n); r.inMonths(t.Month()) && r.onDay(t) {
+			starts = []time.Time{t}
+		}
+	case "WEEKLY":
+		weekStart := day - (int(start.Weekday())+6)%7 + 7*n
+
+		if len(r.byDay) == 0 {
+			starts = []time.Time{at(year, month, day+7*n)}
+		}
+
+		for _, d := range r.byDay {
+			starts = append(starts, at(year, month, weekStart+(int(d.weekday)+6)%7))
+		}
+
+		var inMonths []time.Time
+		for _, t := range starts {
+			if r.inMonths(t.Month()) {
+				inMonths = append(inMonths, t)
+			}
+		}
+
+		starts = inMonths
+	case "MONTHLY":
+		first := time.Date(year, month+time.Month(n), 1, 0, 0, 0, 0, location)
+
+		if r.inMonths(first.Month()) {
+			for _, d := range r.daysOf(first.Year(), first.Month(), day, location) {
+				starts = append(starts, at(first.Year(), first.Month(), d))
+			}
+		}
+	case "YEARLY":
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []time.Month{month}
+		}
+
+		for _, m := range months {
+			for _, d := range r.daysOf(year+n, m, day, location) {
+				starts = append(starts, at(year+n, m, d))
+			}
+		}
+	}
+
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+
+	return r.atPositions(starts)
+}
+
+// daysOf returns the days of month the rule falls on, in order: those its
+// BYMONTHDAY and BYDAY parts pick out, or day if it has neither.
+func (r rrule) daysOf(year int, month time.Month, day int, location *time.Location) []int {
+	days := daysIn(year, month, location)
+
+	if len(r.byDay) == 0 && len(r.byMonthDay) == 0 {
+		if day <= days {
+			return []int{day}
+		}
+
+		return nil
+	}
+
+	byMonthDay := map[int]bool{}
+	for _, d := range r.byMonthDay {
+		if d < 0 {
+			d = days + d + 1
+		}
+
+		byMonthDay[d] = true
+	}
+
+	byDay := map[int]bool{}
+	for _, d := range r.byDay {
+		for _, dom := range weekdaysIn(year, month, d, location) {
+			byDay[dom] = true
+		}
+	}
+
+	var matches []int
+
+	for d := 1; d <= days; d++ {
+		if (len(r.byMonthDay) == 0 || byMonthDay[d]) && (len(r.byDay) == 0 || byDay[d]) {
+			matches = append(matches, d)
+		}
+	}
+
+	return matches
+}
+
+// inMonths reports whether the rule's BYMONTH part, if any, includes month.
+func (r rrule) inMonths(month time.Month) bool {
+	if len(r.byMonth) == 0 {
+		return true
+	}
+
+	for _, m := range r.byMonth {
+		if m == month {
+			return true
+		}
+	}
+
+	return false
+}
+
+// onDay reports whether t is on a day the rule's BYDAY and BYMONTHDAY parts,
+// if any, allow.
+func (r rrule) onDay(t time.Time) bool {
+	if len(r.byDay) == 0 && len(r.byMonthDay) == 0 {
+		return true
+	}
+
+	for _, d := range r.daysOf(t.Year(), t.Month(), t.Day(), t.Location()) {
+		if d == t.Day() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// atPositions returns the starts at the rule's BYSETPOS positions, e.g. just
+// the second for "BYSETPOS=2" or the last for "BYSETPOS=-1", or all of them
+// if it has none.
+func (r rrule) atPositions(starts []time.Time) []time.Time {
+	if len(r.bySetPos) == 0 {
+		return starts
+	}
+
+	picked := map[int]bool{}
+	for _, pos := range r.bySetPos {
+		if pos < 0 {
+			pos += len(starts) + 1
+		}
+
+		if pos >= 1 && pos <= len(starts) {
+			picked[pos-1] = true
+		}
+	}
+
+	var positioned []time.Time
+	for i, start := range starts {
+		if picked[i] {
+			positioned = append(positioned, start)
+		}
+	}
+
+	return positioned
+}
+
+func daysIn(year int, month time.Month, location *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, location).Day()
+}
+
+// weekdaysIn returns the days of month matching d, e.g. every Tuesday for
+// "TU" or just the last Friday for "-1FR".
+func weekdaysIn(year int, month time.Month, d byDay, location *time.Location) []int {
+	var matches []int
+
+	for day := 1; day <= daysIn(year, month, location); day++ {
+		if time.Date(year, month, day, 0, 0, 0, 0, location).Weekday() == d.weekday {
+			matches = append(matches, day)
+		}
+	}
+
+	switch {
+	case d.ordinal > 0 && d.ordinal <= len(matches):
+		return matches[d.ordinal-1 : d.ordinal]
+	case d.ordinal < 0 && -d.ordinal <= len(matches):
+		return matches[len(matches)+d.ordinal : len(matches)+d.ordinal+1]
+	case d.ordinal != 0:
+		return nil
+	default:
+		return matches
+	}
+}
+
+// ImportICS upserts the events in an iCalendar file, as far ahead as the
+// calendar imports recurring events, returning what was imported.
+func (c *Calendar) ImportICS(b *mcdowell.Bot, r io.Reader) (Imported, error) {
+	now := b.Now()
+
+	parsed, err := parseICS(r, c.location, now, now.Add(importHorizon))
+	if err != nil {
+		return Imported{}, err
+	}
+
+	imported := Imported{Events: len(parsed.events), Unexpanded: parsed.unexpanded}
+
+	return imported, c.Import(b, parsed.events, parsed.cancelled...)
+}
+
+// Import upserts imported events. Upcoming events imported before under the
+// same UIDs that are missing from events, e.g. because they were cancelled
+// or moved, are removed, as are upcoming events under the cancelled UIDs.
+func (c *Calendar) Import(b *mcdowell.Bot, events []Event, cancelled ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	uids := map[string]bool{}
+	ids := map[string]bool{}
+
+	for _, uid := range cancelled {
+		uids[uid] = true
+	}
+
+	for _, e := range events {
+		if e.ID == "" {
+			e.ID = eventID(e)
+		}
+
+		if err := c.upsert(b, e); err != nil {
+			return err
+		}
+
+		ids[e.ID] = true
+
+		if e.UID != "" {
+			uids[e.UID] = true
+		}
+	}
+
+	existing, err := Events(b.Store())
+	if err != nil {
+		return err
+	}
+
+	now := b.Now()
+
+	for _, e := range existing {
+		if !uids[e.UID] || ids[e.ID] || e.Start.Before(now) {
+			continue
+		}
+
+		if err := b.Store().Delete(eventsBucket, e.ID); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	return nil
+}
+
+// importFiles imports each of the .ics files at paths.
+func (c *Calendar) importFiles(b *mcdowell.Bot, paths []string) {
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("unable to import events from %s: %v\n", path, err)
+			continue
+		}
+
+		imported, err := c.ImportICS(b, f)
+		f.Close()
+
+		if err != nil {
+			log.Printf("unable to import events from %s: %v\n", path, err)
+			continue
+		}
+
+		if len(imported.Unexpanded) > 0 {
+			log.Printf("only imported the first occurrence of %s from %s, as their rules aren't supported\n", strings.Join(imported.Unexpanded, ", "), path)
+		}
+
+		if b.Debug {
+			log.Printf("imported %d events from %s\n", imported.Events, path)
+		}
+	}
+}
+
+// watchImports imports the .ics files at paths again every so often until
+// the bot shuts down, picking up any changes to them.
+func (c *Calendar) watchImports(b *mcdowell.Bot, paths []string) {
+	ticker := time.NewTicker(importInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.Context().Done():
+			return
+		case <-ticker.C:
+			c.importFiles(b, paths)
+		}
+	}
+}
+
+// onMessage imports any .ics files an admin shares with the bot in a DM.
+func (c *Calendar) onMessage(b *mcdowell.Bot, event interface{}) error {
+	message, ok := event.(*slack.MessageEvent)
+	if !ok || !strings.HasPrefix(message.Channel, "D") || message.User == "" || message.BotID != "" {
+		return nil
+	}
+
+	var replies []string
+
+	for _, file := range message.Files {
+		if !strings.EqualFold(path.Ext(file.Name), ".ics") {
+			continue
+		}
+
+		if !b.IsAdmin(message.User) {
+			replies = []string{"Sorry, only admins can import events."}
+			break
+		}
+
+		var contents bytes.Buffer
+
+		if err := b.Client().GetFile(file.URLPrivateDownload, &contents); err != nil {
+			return errors.Wrapf(err, "unable to download %s", file.Name)
+		}
+
+		imported, err := c.ImportICS(b, &contents)
+		if err != nil {
+			log.Printf("unable to import events from %s: %v\n", file.Name, err)
+			replies = append(replies, fmt.Sprintf("Sorry, I couldn't read %s. Is it an iCalendar file?", file.Name))
+			continue
+		}
+
+		reply := fmt.Sprintf("Imported %d events from %s.", imported.Events, file.Name)
+
+		if len(imported.Unexpanded) > 0 {
+			if len(imported.Unexpanded) == 1 {
+				reply += fmt.Sprintf(" I couldn’t follow how %s repeats, so I only went by its start date.", quoted(imported.Unexpanded))
+			} else {
+				reply += fmt.Sprintf(" I couldn’t follow how %s repeat, so I only went by their start dates.", quoted(imported.Unexpanded))
+			}
+		}
+
+		replies = append(replies, reply)
+	}
+
+	if len(replies) == 0 {
+		return nil
+	}
+
+	_, _, err := b.Client().PostMessage(message.Channel,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(strings.Join(replies, "\n"), false),
+	)
+
+	return errors.WithStack(err)
+}
+
+// quoted lists titles, e.g. "*Book Club* and *Hack Night*".
+func quoted(titles []string) string {
+	list := make([]string, len(titles))
+	for i, title := range titles {
+		list[i] = "*" + title + "*"
+	}
+
+	if len(list) == 1 {
+		return list[0]
+	}
+
+	return strings.Join(list[:len(list)-1], ", ") + " and " + list[len(list)-1]
+}
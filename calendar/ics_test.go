@@ -0,0 +1,238 @@
+package calendar_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell/calendar"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+const meetups = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//ATL Black Tech//Meetups//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:happy-hour@atlblacktech.org\r\n" +
+	"SUMMARY:Tech Happy Hour\r\n" +
+	"DTSTART;TZID=America/New_York:20190627T183000\r\n" +
+	"RRULE:FREQ=MONTHLY;BYDAY=-1TH;COUNT=3\r\n" +
+	"LOCATION:Ponce City Market\\, Atlanta\r\n" +
+	"URL:https://meetup.com/atlblacktech\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:code-and-coffee@atlblacktech.org\r\n" +
+	"SUMMARY:Code and \r\n" +
+	" Coffee\r\n" +
+	"DTSTART:20190601T130000Z\r\n" +
+	"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=SA;UNTIL=20190715T000000Z\r\n" +
+	"EXDATE:20190615T130000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:happy-hour@atlblacktech.org\r\n" +
+	"RECURRENCE-ID;TZID=America/New_York:20190725T183000\r\n" +
+	"SUMMARY:Tech Happy Hour\r\n" +
+	"DTSTART;TZID=America/New_York:20190726T190000\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:picnic@atlblacktech.org\r\n" +
+	"SUMMARY:Summer Picnic\r\n" +
+	"DTSTART;VALUE=DATE:20190720\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParsingICS(t *testing.T) {
+	eastern, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	from := time.Date(2019, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	events, err := calendar.ParseICS(strings.NewReader(meetups), eastern, from, from.AddDate(1, 0, 0))
+	assert.Nil(t, err)
+
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Title+" "+e.Start.In(eastern).Format("2006-01-02 15:04"))
+	}
+
+	assert.Equal(t, []string{
+		"Code and Coffee 2019-06-01 09:00",
+		"Tech Happy Hour 2019-06-27 18:30",
+		"Code and Coffee 2019-06-29 09:00",
+		"Code and Coffee 2019-07-13 09:00",
+		"Tech Happy Hour 2019-07-26 19:00",
+		"Tech Happy Hour 2019-08-29 18:30",
+	}, got)
+
+	assert.Equal(t, "Ponce City Market, Atlanta", events[1].Venue)
+	assert.Equal(t, "https://meetup.com/atlblacktech", events[1].Link)
+	assert.Equal(t, "happy-hour@atlblacktech.org", events[1].UID)
+	assert.Equal(t, "2019-06-27-tech-happy-hour", events[1].ID)
+}
+
+func TestParsingInvalidICS(t *testing.T) {
+	_, err := calendar.ParseICS(strings.NewReader("not a calendar"), time.UTC, time.Time{}, time.Now())
+	assert.NotNil(t, err)
+
+	// Events with rules we can't follow are only included at their start.
+	events, err := calendar.ParseICS(strings.NewReader(hourly), time.UTC, time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, time.July, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "Hourly", events[0].Title)
+		assert.Equal(t, time.Date(2019, time.June, 1, 13, 0, 0, 0, time.UTC), events[0].Start)
+		assert.Equal(t, "Once", events[1].Title)
+	}
+}
+
+// hourly has an event repeating more often than the calendar can follow.
+const hourly = "BEGIN:VCALENDAR\r\n" +
+	"BEGIN:VEVENT\r\nSUMMARY:Hourly\r\nDTSTART:20190601T130000Z\r\nRRULE:FREQ=HOURLY\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nSUMMARY:Once\r\nDTSTART:20190602T130000Z\r\nEND:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParsingICSRulesByMonthAndPosition(t *testing.T) {
+	eastern, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	parse := func(start, rule string) []string {
+		events, err := calendar.ParseICS(strings.NewReader("BEGIN:VCALENDAR\r\n"+
+			"BEGIN:VEVENT\r\nSUMMARY:Meetup\r\nDTSTART;TZID=America/New_York:"+start+"\r\nRRULE:"+rule+"\r\nEND:VEVENT\r\n"+
+			"END:VCALENDAR\r\n"), eastern, time.Date(2019, time.June, 1, 0, 0, 0, 0, eastern), time.Date(2021, time.June, 1, 0, 0, 0, 0, eastern))
+		assert.Nil(t, err)
+
+		var starts []string
+		for _, e := range events {
+			starts = append(starts, e.Start.Format("2006-01-02 15:04"))
+		}
+
+		return starts
+	}
+
+	// The second Tuesday of the month.
+	assert.Equal(t, []string{"2019-06-11 18:30", "2019-07-09 18:30", "2019-08-13 18:30"},
+		parse("20190611T183000", "FREQ=MONTHLY;BYDAY=TU;BYSETPOS=2;COUNT=3"))
+
+	// The last weekday of the month.
+	assert.Equal(t, []string{"2019-06-28 12:00", "2019-07-31 12:00"},
+		parse("20190628T120000", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=2"))
+
+	// Every June 29th.
+	assert.Equal(t, []string{"2019-06-29 10:00", "2020-06-29 10:00"},
+		parse("20190629T100000", "FREQ=YEARLY;BYMONTH=6;BYMONTHDAY=29"))
+
+	// The first Saturday of March and September.
+	assert.Equal(t, []string{"2019-09-07 09:00", "2020-03-07 09:00", "2020-09-05 09:00", "2021-03-06 09:00"},
+		parse("20190907T090000", "FREQ=YEARLY;BYMONTH=3,9;BYDAY=1SA"))
+
+	// Weekdays, only in summer.
+	assert.Equal(t, []string{"2019-08-29 08:00", "2019-08-30 08:00", "2020-06-01 08:00"},
+		parse("20190829T080000", "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;BYMONTH=6,7,8;COUNT=3"))
+}
+
+func TestParsingICSWithWindowsTimeZones(t *testing.T) {
+	events, err := calendar.ParseICS(strings.NewReader("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\nSUMMARY:Demo Day\r\nDTSTART;TZID=\"Eastern Standard Time\":20190602T130000\r\nEND:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"), time.UTC, time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, time.July, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, time.Date(2019, time.June, 2, 17, 0, 0, 0, time.UTC), events[0].Start.UTC())
+	}
+}
+
+func TestParsingICSIgnoresNestedComponents(t *testing.T) {
+	events, err := calendar.ParseICS(strings.NewReader("BEGIN:VCALENDAR\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:demo-day@atlblacktech.org\r\n"+
+		"SUMMARY:Demo Day\r\n"+
+		"DTSTART:20190602T130000Z\r\n"+
+		"BEGIN:VALARM\r\n"+
+		"UID:reminder@atlblacktech.org\r\n"+
+		"ACTION:DISPLAY\r\n"+
+		"DESCRIPTION:Reminder\r\n"+
+		"TRIGGER:-PT1H\r\n"+
+		"END:VALARM\r\n"+
+		"LOCATION:Switchyards\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"), time.UTC, time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC), time.Date(2019, time.July, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+
+	assert.Len(t, events, 1)
+	assert.Equal(t, "demo-day@atlblacktech.org", events[0].UID)
+	assert.Equal(t, "Demo Day", events[0].Title)
+	assert.Equal(t, "Switchyards", events[0].Venue)
+}
+
+func TestImportingICSFromDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "meetups.ics")
+	assert.Nil(t, os.WriteFile(path, []byte(meetups), 0644))
+
+	b, cal, _, _ := newCalendarBot(t, `{"plugins": {"calendar": {"import": ["`+path+`"]}}}`)
+
+	listed := slacktest.Run(t, b, "U0AKEEM", "events")
+	assert.Contains(t, listed, "• *Tech Happy Hour* - Thursday, June 27 at 6:30 PM at Ponce City Market, Atlanta (<https://meetup.com/atlblacktech|details>)")
+	assert.Contains(t, listed, "• *Tech Happy Hour* - Friday, July 26 at 7:00 PM")
+	assert.NotContains(t, listed, "Summer Picnic")
+
+	assert.Contains(t, listed, "• *Tech Happy Hour* - Thursday, August 29 at 6:30 PM")
+
+	// Occurrences that go away in the calendar app are removed.
+	shortened := strings.Replace(meetups, "COUNT=3", "COUNT=1", 1)
+
+	imported, err := cal.ImportICS(b, strings.NewReader(shortened))
+	assert.Nil(t, err)
+	assert.Equal(t, calendar.Imported{Events: 5}, imported)
+
+	listed = slacktest.Run(t, b, "U0AKEEM", "events")
+	assert.NotContains(t, listed, "August 29")
+	assert.Contains(t, listed, "Thursday, June 27 at 6:30 PM")
+	assert.Contains(t, listed, "Code and Coffee")
+
+	// As are events cancelled outright.
+	cancelled := strings.Replace(shortened, "RRULE:FREQ=WEEKLY", "STATUS:CANCELLED\r\nRRULE:FREQ=WEEKLY", 1)
+
+	imported, err = cal.ImportICS(b, strings.NewReader(cancelled))
+	assert.Nil(t, err)
+	assert.Equal(t, calendar.Imported{Events: 2}, imported)
+
+	listed = slacktest.Run(t, b, "U0AKEEM", "events")
+	assert.NotContains(t, listed, "Code and Coffee")
+	assert.Contains(t, listed, "Thursday, June 27 at 6:30 PM")
+}
+
+func TestImportingUploadedICS(t *testing.T) {
+	b, _, fake, _ := newCalendarBot(t, `{}`)
+
+	upload := func(user, name string) {
+		fake.Reset()
+
+		message := &slack.MessageEvent{Msg: slack.Msg{
+			Channel: "D0WILL",
+			User:    user,
+			Files: []slack.File{{
+				Name:               name,
+				URLPrivateDownload: fake.URL + "/files/" + name,
+			}},
+		}}
+
+		assert.Nil(t, b.HandleEvent(message))
+	}
+
+	upload("U0AKEEM", "meetups.ics")
+	assert.Equal(t, []string{"Sorry, only admins can import events."}, fake.Messages())
+	assert.Equal(t, "No upcoming events. Check back soon!", slacktest.Run(t, b, "U0AKEEM", "events"))
+
+	upload(admin, "meetups.ics")
+	assert.Equal(t, []string{"Imported 6 events from meetups.ics."}, fake.Messages())
+	assert.Contains(t, slacktest.Run(t, b, "U0AKEEM", "events"), "Tech Happy Hour")
+
+	// Events with rules the calendar can't follow are called out.
+	upload(admin, "hourly.ics")
+	assert.Equal(t, []string{"Imported 2 events from hourly.ics. I couldn’t follow how *Hourly* repeats, so I only went by its start date."}, fake.Messages())
+}
@@ -0,0 +1,116 @@
+package mcdowell
+
+import (
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+type (
+	// ChannelScope restricts where a trigger may respond. Channels can be
+	// given by ID ("C0123ABCD") or name ("#general" or "general").
+	ChannelScope struct {
+		Allow []string `json:"allow,omitempty"`
+		Deny  []string `json:"deny,omitempty"`
+	}
+
+	// channelSet is a set of channel IDs, along with the names of any channels
+	// that couldn't be resolved to one.
+	channelSet map[string]bool
+)
+
+var channelIDPattern = regexp.MustCompile(`^[CGD][A-Z0-9]{6,}$`)
+
+// isChannelName reports whether channel refers to a channel by name rather than by ID.
+func isChannelName(channel string) bool {
+	return strings.HasPrefix(channel, "#") || !channelIDPattern.MatchString(channel)
+}
+
+func normalizeChannelName(channel string) string {
+	return strings.ToLower(strings.TrimPrefix(channel, "#"))
+}
+
+// names returns every channel named by the scope rather than by ID.
+func (s *ChannelScope) names() []string {
+	var names []string
+
+	for _, channel := range append(append([]string{}, s.Allow...), s.Deny...) {
+		if isChannelName(channel) {
+			names = append(names, normalizeChannelName(channel))
+		}
+	}
+
+	return names
+}
+
+// channelIDs returns the ID of every channel the bot can see, keyed by name.
+func (b *Bot) channelIDs() (map[string]string, error) {
+	ids := map[string]string{}
+
+	params := &slack.GetConversationsParameters{
+		ExcludeArchived: "true",
+		Limit:           200,
+		Types:           []string{"public_channel", "private_channel"},
+	}
+
+	for {
+		channels, cursor, err := b.client.GetConversations(params)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		for _, channel := range channels {
+			ids[channel.Name] = channel.ID
+		}
+
+		if cursor == "" {
+			return ids, nil
+		}
+
+		params.Cursor = cursor
+	}
+}
+
+// resolveChannels turns channels into a channelSet using ids to look up
+// channels given by name.
+func (b *Bot) resolveChannels(channels []string, ids map[string]string) channelSet {
+	set := channelSet{}
+
+	for _, channel := range channels {
+		if !isChannelName(channel) {
+			set[channel] = true
+			continue
+		}
+
+		name := normalizeChannelName(channel)
+
+		if id, ok := ids[name]; ok {
+			set[id] = true
+		} else {
+			log.Printf("unable to resolve channel #%s, matching it by name only\n", name)
+		}
+
+		set[name] = true
+	}
+
+	return set
+}
+
+// resolveChannel returns the ID of channel, given by ID or name, using ids
+// to look up names.
+func resolveChannel(channel string, ids map[string]string) (string, bool) {
+	if !isChannelName(channel) {
+		return channel, true
+	}
+
+	id, ok := ids[normalizeChannelName(channel)]
+
+	return id, ok
+}
+
+func (s channelSet) contains(channel string) bool {
+	return s[channel] || s[normalizeChannelName(channel)]
+}
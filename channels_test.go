@@ -0,0 +1,64 @@
+package mcdowell_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+func TestTriggerChannelScopes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+	writeCatalog(t, path, `{
+		"triggers": [
+			{
+				"phrase": "soul glo",
+				"text": "just let your soul glo",
+				"channels": {"allow": ["#random", "C0000GENERAL", "#nonexistent"]}
+			},
+			{
+				"phrase": "show me the money",
+				"text": "The boy has got his own money!",
+				"channels": {"deny": ["jobs", "#Announcements"]}
+			}
+		]
+	}`)
+
+	m, fake, _ := slacktest.NewBot(t, "", map[string]string{
+		"conversations.list": `{
+			"ok": true,
+			"channels": [
+				{"id": "C0000GENERAL", "name": "general"},
+				{"id": "C00000RANDOM", "name": "random"},
+				{"id": "C000000JOBS", "name": "jobs"},
+				{"id": "C0ANNOUNCEME", "name": "announcements"}
+			]
+		}`,
+	}, mcdowell.WithTriggerCatalog(path))
+
+	responds := func(channel, text string) bool {
+		fake.Reset()
+
+		err := m.OnNewMessage(&slack.MessageEvent{
+			Msg: slack.Msg{
+				Channel: channel,
+				User:    "willmadison",
+				Text:    text,
+			},
+		})
+		assert.Nil(t, err)
+
+		return fake.Last().Method == "chat.postMessage"
+	}
+
+	assert.True(t, responds("C00000RANDOM", "soul glo"))
+	assert.True(t, responds("C0000GENERAL", "soul glo"))
+	assert.False(t, responds("C000000JOBS", "soul glo"))
+
+	assert.True(t, responds("C00000RANDOM", "show me the money"))
+	assert.False(t, responds("C000000JOBS", "show me the money"))
+	assert.False(t, responds("C0ANNOUNCEME", "show me the money"))
+}
@@ -5,6 +5,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"context"
@@ -12,6 +14,9 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/nlopes/slack"
 	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/calendar"
+	"github.com/willmadison/mcdowell/jobs"
+	"github.com/willmadison/mcdowell/mentorship"
 )
 
 var version = "Tip"
@@ -21,20 +26,92 @@ func main() {
 
 	botToken := os.Getenv("ABT_SLACK_BOT_TOKEN")
 	devMode := os.Getenv("ABT_SLACK_BOT_DEV_MODE") == "true"
+	triggerCatalog := os.Getenv("ABT_SLACK_BOT_TRIGGERS")
+	matchPolicy := os.Getenv("ABT_SLACK_BOT_MATCH_POLICY")
+	signingSecret := os.Getenv("ABT_SLACK_BOT_SIGNING_SECRET")
+	eventsMode := os.Getenv("ABT_SLACK_BOT_EVENTS_MODE")
+	appToken := os.Getenv("ABT_SLACK_BOT_APP_TOKEN")
+	configPath := os.Getenv("ABT_SLACK_BOT_CONFIG")
+	storePath := os.Getenv("ABT_SLACK_BOT_STORE")
+
+	if eventsMode == "" {
+		eventsMode = "rtm"
+	}
 
-	options := []func(*mcdowell.Bot){mcdowell.Versioned(version)}
+	options := []func(*mcdowell.Bot){
+		mcdowell.Versioned(version),
+		mcdowell.WithPlugin(calendar.New()),
+		mcdowell.WithPlugin(jobs.New()),
+		mcdowell.WithPlugin(mentorship.New()),
+	}
 
 	if devMode {
 		options = append(options, mcdowell.WithDebug())
 	}
 
+	if triggerCatalog != "" {
+		options = append(options, mcdowell.WithTriggerCatalog(triggerCatalog))
+	}
+
+	if matchPolicy != "" {
+		options = append(options, mcdowell.WithMatchPolicy(mcdowell.MatchPolicy(matchPolicy)))
+	}
+
+	if signingSecret != "" {
+		options = append(options, mcdowell.WithSigningSecret(signingSecret))
+	}
+
+	if configPath != "" {
+		config, err := mcdowell.LoadConfig(configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		options = append(options, mcdowell.WithConfig(config))
+	}
+
+	if storePath != "" {
+		store, err := mcdowell.OpenFileStore(storePath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+
+		options = append(options, mcdowell.WithStore(store))
+	} else {
+		log.Println("no store set, the bot's state will be lost when it restarts")
+	}
+
 	if botToken == "" {
 		log.Fatalln("slack bot token is required for proper operation!")
 	}
 
+	options = append(options, mcdowell.WithAPIToken(botToken))
+
 	client := slack.New(botToken)
-	rtm := client.NewRTM()
-	go rtm.ManageConnection()
+
+	var source mcdowell.EventSource
+	var eventsAPI *mcdowell.EventsAPI
+
+	switch eventsMode {
+	case "rtm":
+		source = mcdowell.NewRTMSource(client)
+	case "events":
+		if signingSecret == "" {
+			log.Fatalln("a signing secret is required to receive events from the Events API!")
+		}
+
+		eventsAPI = mcdowell.NewEventsAPI(signingSecret)
+		source = eventsAPI
+	case "socket":
+		if appToken == "" {
+			log.Fatalln("an app-level token is required to receive events over Socket Mode!")
+		}
+
+		source = mcdowell.NewSocketMode(appToken)
+	default:
+		log.Fatalf("unknown events mode %q, expected rtm, events or socket\n", eventsMode)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -45,18 +122,20 @@ func main() {
 	}
 
 	go func() {
-		log.Println("listening for incoming events from Slack...")
-
-		for msg := range rtm.IncomingEvents {
-			switch message := msg.Data.(type) {
-			case *slack.MessageEvent:
-				go bot.OnNewMessage(message)
-			case *slack.TeamJoinEvent:
-				go bot.OnTeamJoined(message)
-			}
+		hangups := make(chan os.Signal, 1)
+		signal.Notify(hangups, syscall.SIGHUP)
+
+		for range hangups {
+			log.Println("received SIGHUP, reloading trigger catalog...")
+			bot.ReloadTriggers()
 		}
 	}()
 
+	go func() {
+		log.Printf("listening for incoming events from Slack (%s)...\n", eventsMode)
+		log.Fatal(bot.Listen(ctx, source))
+	}()
+
 	go func() {
 		r := mux.NewRouter()
 
@@ -68,6 +147,17 @@ func main() {
 			}`)
 		}).Name("healthCheck").Methods("GET")
 
+		if signingSecret != "" {
+			r.HandleFunc("/slack/commands", bot.HandleSlashCommand).Name("slashCommands").Methods("POST")
+			r.HandleFunc("/slack/interactions", bot.HandleInteraction).Name("interactions").Methods("POST")
+		} else {
+			log.Println("no signing secret set, slash commands and interactivity are disabled")
+		}
+
+		if eventsAPI != nil {
+			r.Handle("/slack/events", eventsAPI).Name("events").Methods("POST")
+		}
+
 		s := http.Server{
 			Addr:         ":8088",
 			Handler:      r,
@@ -75,7 +165,7 @@ func main() {
 			WriteTimeout: 10 * time.Second,
 		}
 
-		log.Println("serving healthCheck request(s) on", s.Addr)
+		log.Println("serving HTTP request(s) on", s.Addr)
 		log.Fatal(s.ListenAndServe())
 	}()
 
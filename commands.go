@@ -0,0 +1,247 @@
+package mcdowell
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// CommandRequest is a single invocation of a bot command.
+	CommandRequest struct {
+		Command   string
+		Args      []string
+		UserID    string
+		UserName  string
+		ChannelID string
+		TriggerID string
+	}
+
+	// CommandHandler runs a command and returns the text to reply with.
+	// Handlers that reply themselves, e.g. with buttons, return no text.
+	CommandHandler func(b *Bot, req *CommandRequest) (string, error)
+
+	// Command is something members can ask the bot to do, e.g. "/mcdowell help".
+	// AdminOnly commands can only be run by the bot's admins.
+	Command struct {
+		Name        string
+		Usage       string
+		Description string
+		AdminOnly   bool
+		Handler     CommandHandler
+	}
+)
+
+// RegisterCommand makes c available to members.
+func (b *Bot) RegisterCommand(c Command) error {
+	c.Name = strings.ToLower(c.Name)
+
+	if c.Name == "" || strings.ContainsAny(c.Name, " \t\n") {
+		return errors.Errorf("invalid command name %q", c.Name)
+	}
+
+	if c.Handler == nil {
+		return errors.Errorf("command %q has no handler", c.Name)
+	}
+
+	if c.Usage == "" {
+		c.Usage = c.Name
+	}
+
+	b.commandsMu.Lock()
+	defer b.commandsMu.Unlock()
+
+	if b.commands == nil {
+		b.commands = map[string]Command{}
+	}
+
+	if _, ok := b.commands[c.Name]; ok {
+		return errors.Errorf("command %q is already registered", c.Name)
+	}
+
+	b.commands[c.Name] = c
+
+	return nil
+}
+
+// Commands returns every registered command, sorted by name.
+func (b *Bot) Commands() []Command {
+	b.commandsMu.RLock()
+	defer b.commandsMu.RUnlock()
+
+	commands := make([]Command, 0, len(b.commands))
+	for _, c := range b.commands {
+		commands = append(commands, c)
+	}
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+
+	return commands
+}
+
+func (b *Bot) hasCommand(name string) bool {
+	b.commandsMu.RLock()
+	defer b.commandsMu.RUnlock()
+
+	_, ok := b.commands[name]
+
+	return ok
+}
+
+// ParseCommand splits text like `events add "Tech Happy Hour" 2019-06-29` into
+// a command name and its arguments. Arguments with spaces can be wrapped in
+// double, single or curly quotes; an unterminated quote runs to the end of text.
+func ParseCommand(text string) (string, []string) {
+	tokens := Tokenize(text)
+	if len(tokens) == 0 {
+		return "", nil
+	}
+
+	return strings.ToLower(tokens[0]), tokens[1:]
+}
+
+// closingQuotes maps each quote an argument can be wrapped in to the one that ends it.
+var closingQuotes = map[rune]rune{
+	'"':      '"',
+	'\'':     '\'',
+	'\u201c': '\u201d', // “ ”
+	'\u2018': '\u2019', // ‘ ’
+}
+
+// Tokenize splits text on whitespace, keeping quoted runs together.
+func Tokenize(text string) []string {
+	var (
+		tokens  []string
+		token   strings.Builder
+		inToken bool
+		closing rune
+	)
+
+	for _, r := range text {
+		switch {
+		case closing != 0:
+			if r == closing {
+				closing = 0
+			} else {
+				token.WriteRune(r)
+			}
+		case closingQuotes[r] != 0 && !inToken:
+			closing = closingQuotes[r]
+			inToken = true
+		case unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens
+}
+
+// RunCommand runs the command req asks for, returning the text to reply with.
+// Without a command it runs help.
+func (b *Bot) RunCommand(req *CommandRequest) (string, error) {
+	if req.Command == "" {
+		req.Command = "help"
+	}
+
+	b.commandsMu.RLock()
+	c, ok := b.commands[req.Command]
+	b.commandsMu.RUnlock()
+
+	if !ok {
+		return fmt.Sprintf("Sorry, I don't know how to `%s`. Try `help` to see what I can do.", req.Command), nil
+	}
+
+	if c.AdminOnly && !b.IsAdmin(req.UserID) {
+		return fmt.Sprintf("Sorry, only admins can `%s`.", c.Name), nil
+	}
+
+	if b.Debug || b.Testing {
+		log.Printf("running command %q for %s with args %q\n", c.Name, req.UserID, req.Args)
+	}
+
+	reply, err := c.Handler(b, req)
+	if err != nil {
+		return "", errors.Wrapf(err, "command %q failed", c.Name)
+	}
+
+	return reply, nil
+}
+
+func (b *Bot) registerBuiltinCommands() error {
+	for _, c := range []Command{
+		{
+			Name:        "help",
+			Usage:       "help [command]",
+			Description: "Lists everything I can do, or explains a single command.",
+			Handler:     help,
+		},
+		{
+			Name:        "leaderboard",
+			Description: "Shows who has the most karma this week, this month and of all time.",
+			Handler:     leaderboard,
+		},
+		{
+			Name:        "reload",
+			Description: "Reloads the trigger catalog.",
+			AdminOnly:   true,
+			Handler:     reload,
+		},
+	} {
+		if err := b.RegisterCommand(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func help(b *Bot, req *CommandRequest) (string, error) {
+	commands := b.Commands()
+
+	if len(req.Args) > 0 {
+		name := strings.ToLower(req.Args[0])
+
+		for _, c := range commands {
+			if c.Name == name {
+				return fmt.Sprintf("`%s` - %s", c.Usage, c.describe()), nil
+			}
+		}
+
+		return fmt.Sprintf("Sorry, I don't know how to `%s`.", name), nil
+	}
+
+	var text strings.Builder
+
+	text.WriteString("Here's what I can do:\n")
+	for _, c := range commands {
+		fmt.Fprintf(&text, "• `%s` - %s\n", c.Usage, c.describe())
+	}
+
+	return strings.TrimSuffix(text.String(), "\n"), nil
+}
+
+// describe returns c's description, noting whether it's for admins only.
+func (c Command) describe() string {
+	if c.AdminOnly {
+		return c.Description + " (admins only)"
+	}
+
+	return c.Description
+}
@@ -0,0 +1,139 @@
+package mcdowell
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Config holds the community specific settings organizers can change without
+// touching any Go code. Every section is optional.
+type Config struct {
+	Roster        *RosterConfig        `json:"roster,omitempty"`
+	Welcome       *WelcomeConfig       `json:"welcome,omitempty"`
+	Onboarding    *OnboardingConfig    `json:"onboarding,omitempty"`
+	Introductions *IntroductionsConfig `json:"introductions,omitempty"`
+	Wins          *WinsConfig          `json:"wins,omitempty"`
+	Newsletter    *NewsletterConfig    `json:"newsletter,omitempty"`
+
+	// TimeZone is where scheduled jobs run, e.g. "America/New_York".
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// Plugins holds each plugin's own config, keyed by plugin name.
+	Plugins map[string]json.RawMessage `json:"plugins,omitempty"`
+}
+
+// LoadConfig reads the JSON bot configuration stored at path.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	config, err := ParseConfig(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid config %s", path)
+	}
+
+	return config, nil
+}
+
+// ParseConfig decodes and validates a JSON bot configuration.
+func ParseConfig(r io.Reader) (*Config, error) {
+	var config Config
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&config); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+func (c *Config) validate() error {
+	if c.Roster != nil {
+		if err := c.Roster.validate(); err != nil {
+			return errors.Wrap(err, "roster")
+		}
+	}
+
+	if c.Welcome != nil {
+		if err := c.Welcome.validate(); err != nil {
+			return errors.Wrap(err, "welcome")
+		}
+	}
+
+	if c.Onboarding != nil {
+		if err := c.Onboarding.validate(); err != nil {
+			return errors.Wrap(err, "onboarding")
+		}
+	}
+
+	if c.Introductions != nil {
+		if err := c.Introductions.validate(); err != nil {
+			return errors.Wrap(err, "introductions")
+		}
+	}
+
+	if c.Wins != nil {
+		if err := c.Wins.validate(); err != nil {
+			return errors.Wrap(err, "wins")
+		}
+	}
+
+	if c.Newsletter != nil {
+		if err := c.Newsletter.validate(); err != nil {
+			return errors.Wrap(err, "newsletter")
+		}
+	}
+
+	if c.TimeZone != "" {
+		if _, err := time.LoadLocation(c.TimeZone); err != nil {
+			return errors.Wrapf(err, "unknown time zone %q", c.TimeZone)
+		}
+	}
+
+	return nil
+}
+
+// resolveConfigChannels looks up the IDs of every channel named in the config.
+func (b *Bot) resolveConfigChannels() error {
+	if b.welcome() == nil && b.onboarding() == nil && b.introductions() == nil && b.wins() == nil && b.newsletter() == nil && b.rosterConfig().OpsChannel == "" {
+		return nil
+	}
+
+	ids, err := b.channelIDs()
+	if err != nil {
+		return errors.Wrap(err, "unable to resolve configured channels")
+	}
+
+	b.resolveFeaturedChannels(ids)
+	b.resolveOnboardingChannels(ids)
+
+	if err := b.resolveOpsChannel(ids); err != nil {
+		return err
+	}
+
+	if err := b.resolveIntroductionsChannel(ids); err != nil {
+		return err
+	}
+
+	return b.resolveScheduledChannels(ids)
+}
+
+// WithConfig applies the community specific settings in config to the bot.
+func WithConfig(config *Config) func(*Bot) {
+	return func(b *Bot) {
+		b.config = config
+	}
+}
@@ -0,0 +1,148 @@
+package mcdowell
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cooldown notices tell the bot what to do when a trigger is set off while
+// it's cooling down.
+const (
+	// NoticeSilent ignores the message.
+	NoticeSilent = "silent"
+	// NoticeEphemeral lets the person who set the trigger off know, privately,
+	// when they can try again.
+	NoticeEphemeral = "ephemeral"
+)
+
+// maxCooldownEntries is how many cooldowns are tracked before expired ones are pruned.
+const maxCooldownEntries = 1024
+
+type (
+	// Duration is a time.Duration that reads and writes JSON as a string like "1m30s".
+	Duration struct {
+		time.Duration
+	}
+
+	// Cooldown limits how often a trigger may respond. Each window is
+	// independent and a trigger only responds once all of them have elapsed.
+	Cooldown struct {
+		Trigger Duration `json:"trigger,omitempty"`
+		Channel Duration `json:"channel,omitempty"`
+		User    Duration `json:"user,omitempty"`
+		Notice  string   `json:"notice,omitempty"`
+		Message string   `json:"message,omitempty"`
+	}
+
+	// cooldowns tracks when triggers may next respond, keyed by trigger and scope.
+	cooldowns struct {
+		mu    sync.Mutex
+		until map[string]time.Time
+	}
+)
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return errors.WithStack(err)
+	}
+
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if duration < 0 {
+		return errors.Errorf("negative duration %q", s)
+	}
+
+	d.Duration = duration
+
+	return nil
+}
+
+func (c *Cooldown) validate() error {
+	switch c.Notice {
+	case "", NoticeSilent, NoticeEphemeral:
+		return nil
+	default:
+		return errors.Errorf("unknown cooldown notice %q", c.Notice)
+	}
+}
+
+// notice returns the text of the ephemeral notice sent when a trigger is
+// set off with remaining left on its cooldown.
+func (c *Cooldown) notice(remaining time.Duration) string {
+	if c.Message != "" {
+		return c.Message
+	}
+
+	return fmt.Sprintf("Easy now, that one's cooling down. Try again in %s.", remaining.Round(time.Second))
+}
+
+func newCooldowns() *cooldowns {
+	return &cooldowns{until: map[string]time.Time{}}
+}
+
+// allow reports whether t may respond in channel to user at now, starting
+// its cooldown windows if so. Otherwise it returns how long is left on the
+// longest window still running.
+func (c *cooldowns) allow(t trigger, channel, user string, now time.Time) (time.Duration, bool) {
+	if t.Cooldown == nil {
+		return 0, true
+	}
+
+	windows := map[string]time.Duration{
+		"trigger:" + t.Phrase:                 t.Cooldown.Trigger.Duration,
+		"channel:" + t.Phrase + ":" + channel: t.Cooldown.Channel.Duration,
+		"user:" + t.Phrase + ":" + user:       t.Cooldown.User.Duration,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var remaining time.Duration
+	for key, window := range windows {
+		if window <= 0 {
+			continue
+		}
+
+		if left := c.until[key].Sub(now); left > remaining {
+			remaining = left
+		}
+	}
+
+	if remaining > 0 {
+		return remaining, false
+	}
+
+	if len(c.until) >= maxCooldownEntries {
+		c.prune(now)
+	}
+
+	for key, window := range windows {
+		if window > 0 {
+			c.until[key] = now.Add(window)
+		}
+	}
+
+	return 0, true
+}
+
+func (c *cooldowns) prune(now time.Time) {
+	for key, until := range c.until {
+		if !until.After(now) {
+			delete(c.until, key)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package mcdowell_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+func TestTriggerCooldowns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+	writeCatalog(t, path, `{
+		"triggers": [
+			{
+				"phrase": "soul glo",
+				"image_url": "https://media.giphy.com/media/3Gz3vy81HkDa8/giphy.gif",
+				"cooldown": {"channel": "10m", "user": "1h"}
+			}
+		]
+	}`)
+
+	m, fake, clock := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog(path))
+
+	soulGlo := func(channel, user string) bool {
+		fake.Reset()
+
+		err := m.OnNewMessage(&slack.MessageEvent{
+			Msg: slack.Msg{
+				Channel: channel,
+				User:    user,
+				Text:    "just let your soul glo",
+			},
+		})
+		assert.Nil(t, err)
+
+		return fake.Last().Method == "chat.postMessage"
+	}
+
+	assert.True(t, soulGlo("C1", "darryl"))
+	assert.False(t, soulGlo("C1", "darryl"), "channel and user are cooling down")
+	assert.False(t, soulGlo("C1", "akeem"), "channel is cooling down")
+	assert.True(t, soulGlo("C2", "akeem"))
+	assert.False(t, soulGlo("C3", "darryl"), "user is cooling down")
+
+	clock.Add(11 * time.Minute)
+
+	assert.True(t, soulGlo("C1", "semmi"))
+	assert.False(t, soulGlo("C4", "darryl"), "user is still cooling down")
+
+	clock.Add(time.Hour)
+
+	assert.True(t, soulGlo("C4", "darryl"))
+}
+
+func TestTriggerCooldownEphemeralNotice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+	writeCatalog(t, path, `{
+		"triggers": [
+			{
+				"phrase": "soul glo",
+				"image_url": "https://media.giphy.com/media/3Gz3vy81HkDa8/giphy.gif",
+				"cooldown": {"trigger": "5m", "notice": "ephemeral"}
+			}
+		]
+	}`)
+
+	m, fake, clock := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog(path))
+
+	e := &slack.MessageEvent{
+		Msg: slack.Msg{
+			Channel: "C1",
+			User:    "darryl",
+			Text:    "soul glo",
+		},
+	}
+
+	assert.Nil(t, m.OnNewMessage(e))
+	assert.Equal(t, "chat.postMessage", fake.Last().Method)
+
+	clock.Add(2 * time.Minute)
+
+	assert.Nil(t, m.OnNewMessage(e))
+	assert.Equal(t, "chat.postEphemeral", fake.Last().Method)
+	assert.Equal(t, "darryl", fake.Last().Form.Get("user"))
+	assert.Equal(t, "Easy now, that one's cooling down. Try again in 3m0s.", fake.Last().Form.Get("text"))
+}
+
+func TestInvalidCooldownIsRejected(t *testing.T) {
+	for _, catalog := range []string{
+		`{"triggers": [{"phrase": "queen", "text": "?", "cooldown": {"user": "forever"}}]}`,
+		`{"triggers": [{"phrase": "queen", "text": "?", "cooldown": {"user": "-1m"}}]}`,
+		`{"triggers": [{"phrase": "queen", "text": "?", "cooldown": {"notice": "loudly"}}]}`,
+	} {
+		_, err := mcdowell.ParseTriggerCatalog(strings.NewReader(catalog))
+		assert.NotNil(t, err, catalog)
+	}
+}
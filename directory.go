@@ -0,0 +1,229 @@
+package mcdowell
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+type (
+	// Member is someone in the workspace, as the bot knows them.
+	Member struct {
+		ID          string
+		Name        string
+		DisplayName string
+		RealName    string
+		Email       string
+		IsBot       bool
+		Deleted     bool
+	}
+
+	// Directory caches everyone in the workspace so handlers can look people
+	// up by ID, username, display name or email without asking Slack. Name,
+	// display name and email lookups are case insensitive.
+	Directory struct {
+		mu            sync.RWMutex
+		byID          map[string]Member
+		byName        map[string]string
+		byDisplayName map[string]string
+		byEmail       map[string]string
+	}
+)
+
+func newMember(user slack.User) Member {
+	return Member{
+		ID:          user.ID,
+		Name:        user.Name,
+		DisplayName: user.Profile.DisplayName,
+		RealName:    user.RealName,
+		Email:       user.Profile.Email,
+		IsBot:       user.IsBot || user.ID == "USLACKBOT",
+		Deleted:     user.Deleted,
+	}
+}
+
+// Addressed returns how to address m in a message: their display name,
+// falling back to their username.
+func (m Member) Addressed() string {
+	if m.DisplayName != "" {
+		return m.DisplayName
+	}
+
+	return m.Name
+}
+
+func newDirectory() *Directory {
+	return &Directory{
+		byID:          map[string]Member{},
+		byName:        map[string]string{},
+		byDisplayName: map[string]string{},
+		byEmail:       map[string]string{},
+	}
+}
+
+// Update adds user to the directory or refreshes what it knows about them.
+func (d *Directory) Update(user slack.User) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.update(newMember(user))
+}
+
+func (d *Directory) update(m Member) {
+	if previous, ok := d.byID[m.ID]; ok {
+		delete(d.byName, strings.ToLower(previous.Name))
+		delete(d.byDisplayName, strings.ToLower(previous.DisplayName))
+		delete(d.byEmail, strings.ToLower(previous.Email))
+	}
+
+	d.byID[m.ID] = m
+
+	if m.Name != "" {
+		d.byName[strings.ToLower(m.Name)] = m.ID
+	}
+
+	if m.DisplayName != "" {
+		d.byDisplayName[strings.ToLower(m.DisplayName)] = m.ID
+	}
+
+	if m.Email != "" {
+		d.byEmail[strings.ToLower(m.Email)] = m.ID
+	}
+}
+
+// replace swaps the whole directory for members.
+func (d *Directory) replace(members []Member) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.byID = map[string]Member{}
+	d.byName = map[string]string{}
+	d.byDisplayName = map[string]string{}
+	d.byEmail = map[string]string{}
+
+	for _, m := range members {
+		d.update(m)
+	}
+}
+
+// ByID looks up the member with id.
+func (d *Directory) ByID(id string) (Member, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	m, ok := d.byID[id]
+
+	return m, ok
+}
+
+// ByName looks up the member with username name.
+func (d *Directory) ByName(name string) (Member, bool) {
+	return d.lookup(&d.byName, strings.TrimPrefix(name, "@"))
+}
+
+// ByDisplayName looks up the member with displayName.
+func (d *Directory) ByDisplayName(displayName string) (Member, bool) {
+	return d.lookup(&d.byDisplayName, displayName)
+}
+
+// ByEmail looks up the member with email.
+func (d *Directory) ByEmail(email string) (Member, bool) {
+	return d.lookup(&d.byEmail, email)
+}
+
+// lookup finds the member index maps key to. index points at one of d's
+// indexes since they're replaced wholesale on reload.
+func (d *Directory) lookup(index *map[string]string, key string) (Member, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	id, ok := (*index)[strings.ToLower(key)]
+	if !ok {
+		return Member{}, false
+	}
+
+	m, ok := d.byID[id]
+
+	return m, ok
+}
+
+// Members returns how many people, not bots, are in the workspace.
+func (d *Directory) Members() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var count int
+
+	for _, m := range d.byID {
+		if !m.IsBot && !m.Deleted {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Addressed returns how to address the member with id in a message, or an
+// @-mention if they aren't in the directory.
+func (d *Directory) Addressed(id string) string {
+	if m, ok := d.ByID(id); ok && m.Addressed() != "" {
+		return m.Addressed()
+	}
+
+	return "<@" + id + ">"
+}
+
+// Directory returns the bot's cache of everyone in the workspace.
+func (b *Bot) Directory() *Directory {
+	return b.directory
+}
+
+// loadDirectory fills the directory with everyone in the workspace, a page
+// at a time, waiting out any rate limiting.
+func (b *Bot) loadDirectory() error {
+	var members []Member
+
+	page := b.client.GetUsersPaginated()
+
+	for {
+		// Next hands back a page that looks finished when it fails, so the
+		// current page is kept to retry from.
+		next, err := page.Next(b.ctx)
+		if next.Done(err) {
+			break
+		}
+
+		if rateLimited, ok := err.(*slack.RateLimitedError); ok {
+			select {
+			case <-b.ctx.Done():
+				return errors.WithStack(b.ctx.Err())
+			case <-time.After(rateLimited.RetryAfter):
+				continue
+			}
+		}
+
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		page = next
+
+		for _, user := range page.Users {
+			members = append(members, newMember(user))
+		}
+	}
+
+	b.directory.replace(members)
+
+	return nil
+}
+
+// OnUserChanged keeps the directory up to date when someone changes their profile.
+func (b *Bot) OnUserChanged(event *slack.UserChangeEvent) error {
+	b.directory.Update(event.User)
+
+	return nil
+}
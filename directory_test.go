@@ -0,0 +1,113 @@
+package mcdowell_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+// usersPages are the pages of users.list.
+var usersPages = map[string]string{
+	"users.list": `{
+		"ok": true,
+		"members": [
+			{"id": "U0AKEEM", "name": "akeem", "real_name": "Akeem Joffer", "profile": {"display_name": "Prince Akeem", "email": "akeem@zamunda.gov"}},
+			{"id": "U0BOT", "name": "mcdowell", "is_bot": true}
+		],
+		"response_metadata": {"next_cursor": "page2"}
+	}`,
+	"users.list?cursor=page2": `{
+		"ok": true,
+		"members": [
+			{"id": "U0SEMMI", "name": "semmi", "profile": {"email": "semmi@zamunda.gov"}},
+			{"id": "U0GONE", "name": "gone", "deleted": true}
+		],
+		"response_metadata": {"next_cursor": ""}
+	}`,
+}
+
+func TestDirectoryLoadsEveryPage(t *testing.T) {
+	b, _, _ := slacktest.NewBot(t, "", usersPages)
+	directory := b.Directory()
+
+	akeem, ok := directory.ByID("U0AKEEM")
+	assert.True(t, ok)
+	assert.Equal(t, mcdowell.Member{
+		ID:          "U0AKEEM",
+		Name:        "akeem",
+		DisplayName: "Prince Akeem",
+		RealName:    "Akeem Joffer",
+		Email:       "akeem@zamunda.gov",
+	}, akeem)
+
+	semmi, ok := directory.ByName("@Semmi")
+	assert.True(t, ok)
+	assert.Equal(t, "U0SEMMI", semmi.ID)
+
+	assert.Equal(t, 2, directory.Members())
+}
+
+func TestDirectoryLoadingWaitsOutRateLimits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := slacktest.NewServer(t, usersPages)
+	fake.RateLimit("users.list", 1)
+
+	m, err := mcdowell.NewBot(ctx, fake.Client(), mcdowell.WithTesting())
+	assert.Nil(t, err)
+
+	directory := m.Directory()
+
+	assert.Equal(t, 2, directory.Members())
+
+	_, ok := directory.ByID("U0SEMMI")
+	assert.True(t, ok)
+}
+
+func TestDirectoryLookups(t *testing.T) {
+	b, _, _ := slacktest.NewBot(t, "", usersPages)
+	directory := b.Directory()
+
+	m, ok := directory.ByDisplayName("prince akeem")
+	assert.True(t, ok)
+	assert.Equal(t, "U0AKEEM", m.ID)
+
+	m, ok = directory.ByEmail("Semmi@Zamunda.gov")
+	assert.True(t, ok)
+	assert.Equal(t, "U0SEMMI", m.ID)
+
+	_, ok = directory.ByName("cleo")
+	assert.False(t, ok)
+
+	assert.Equal(t, "Prince Akeem", directory.Addressed("U0AKEEM"))
+	assert.Equal(t, "semmi", directory.Addressed("U0SEMMI"))
+	assert.Equal(t, "<@U0CLEO>", directory.Addressed("U0CLEO"))
+}
+
+func TestDirectoryIsRefreshedByEvents(t *testing.T) {
+	m, _, _ := slacktest.NewBot(t, "", usersPages)
+
+	err := m.HandleEvent(&slack.UserChangeEvent{
+		Type: "user_change",
+		User: slack.User{ID: "U0SEMMI", Name: "semmi", Profile: slack.UserProfile{DisplayName: "Sexual Chocolate"}},
+	})
+	assert.Nil(t, err)
+
+	semmi, ok := m.Directory().ByDisplayName("sexual chocolate")
+	assert.True(t, ok)
+	assert.Equal(t, "U0SEMMI", semmi.ID)
+
+	_, ok = m.Directory().ByEmail("semmi@zamunda.gov")
+	assert.False(t, ok)
+
+	err = m.HandleEvent(&slack.TeamJoinEvent{User: slack.User{ID: "U0CLEO", Name: "cleo"}})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "cleo", m.Directory().Addressed("U0CLEO"))
+	assert.Equal(t, 3, m.Directory().Members())
+}
@@ -0,0 +1,108 @@
+package mcdowell
+
+import (
+	"context"
+
+	"github.com/nlopes/slack"
+)
+
+type (
+	// EventSource delivers events from Slack, as the same types the RTM API
+	// uses (e.g. *slack.MessageEvent), so the bot doesn't care how they arrive.
+	EventSource interface {
+		// Listen sends events to events until ctx is done or the source fails.
+		Listen(ctx context.Context, events chan<- interface{}) error
+	}
+
+	// RTMSource receives events over Slack's RTM API.
+	RTMSource struct {
+		rtm *slack.RTM
+	}
+
+	// request is an event its source needs the bot's answer to right away,
+	// e.g. to acknowledge it with over Socket Mode. The bot handles it and
+	// sends what to answer with on reply, or nil if there's nothing to say.
+	request struct {
+		event interface{}
+		reply chan<- interface{}
+	}
+)
+
+// NewRTMSource returns an EventSource receiving events over client's RTM connection.
+func NewRTMSource(client *slack.Client) *RTMSource {
+	return &RTMSource{rtm: client.NewRTM()}
+}
+
+// Listen implements EventSource.
+func (s *RTMSource) Listen(ctx context.Context, events chan<- interface{}) error {
+	go s.rtm.ManageConnection()
+	defer s.rtm.Disconnect()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-s.rtm.IncomingEvents:
+			if !ok {
+				return nil
+			}
+
+			select {
+			case events <- msg.Data:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// Listen handles every event source delivers until ctx is done or the source fails.
+func (b *Bot) Listen(ctx context.Context, source EventSource) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan interface{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- source.Listen(ctx, events)
+	}()
+
+	for {
+		select {
+		case event := <-events:
+			if r, ok := event.(*request); ok {
+				go func() {
+					r.reply <- b.answer(r.event)
+				}()
+
+				continue
+			}
+
+			b.dispatch(event)
+		case err := <-done:
+			return err
+		}
+	}
+}
+
+// answer handles an event its source needs an answer to, returning the answer.
+func (b *Bot) answer(event interface{}) interface{} {
+	switch e := event.(type) {
+	case *ViewSubmission:
+		if problems := b.checkSubmission(e); problems != nil {
+			return problems
+		}
+	case *slack.SlashCommand:
+		reply := b.runSlashCommand(*e)
+		b.dispatchTo(b.runPlugins, e)
+
+		if reply != "" {
+			return slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: reply}
+		}
+	default:
+		b.dispatch(event)
+	}
+
+	return nil
+}
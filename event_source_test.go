@@ -0,0 +1,65 @@
+package mcdowell_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+// replaySource is an EventSource delivering a fixed list of events.
+type replaySource []interface{}
+
+func (s replaySource) Listen(ctx context.Context, events chan<- interface{}) error {
+	for _, event := range s {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func TestBotListensToEventSources(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := slacktest.NewServer(t, nil)
+
+	m, err := mcdowell.NewBot(ctx, fake.Client(), mcdowell.WithTesting())
+	assert.Nil(t, err)
+
+	fake.Reset()
+
+	err = m.Listen(ctx, replaySource{
+		&slack.HelloEvent{},
+		&slack.MessageEvent{Msg: slack.Msg{Channel: "C0000GENERAL", User: "U0AKEEM", Text: "soul glo"}},
+		&slack.TeamJoinEvent{User: slack.User{ID: "U0SEMMI", Name: "Semmi"}},
+		&slack.SlashCommand{ChannelID: "C0000GENERAL", UserID: "U0AKEEM", Command: "/mcdowell", Text: "help", ResponseURL: fake.URL + "/commands/T0ATLBT/1234"},
+	})
+	assert.Nil(t, err)
+
+	posts := fake.Calls("chat.postMessage")
+	if assert.Len(t, posts, 2) {
+		assert.Equal(t, "C0000GENERAL", posts[0].Form.Get("channel"))
+		assert.Equal(t, "U0SEMMI", posts[1].Form.Get("channel"))
+	}
+
+	// Slash commands are answered at their response URL, which works in
+	// channels the bot isn't in.
+	assert.Empty(t, fake.Calls("chat.postEphemeral"))
+
+	responses := fake.Calls("commands/T0ATLBT/1234")
+	if assert.Len(t, responses, 1) {
+		var reply slack.Msg
+		assert.Nil(t, json.Unmarshal(responses[0].Body, &reply))
+		assert.Equal(t, slack.ResponseTypeEphemeral, reply.ResponseType)
+		assert.Contains(t, reply.Text, "Here's what I can do")
+	}
+}
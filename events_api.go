@@ -0,0 +1,193 @@
+package mcdowell
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// Events API envelope types.
+const (
+	eventsAPIURLVerification = "url_verification"
+	eventsAPICallback        = "event_callback"
+)
+
+// eventRetention is how long delivered event IDs are remembered so Slack's
+// retries aren't handled twice.
+const eventRetention = time.Hour
+
+type (
+	// EventsAPI is an EventSource receiving events from Slack's Events API.
+	// It's also the http.Handler Slack's event requests must be routed to.
+	EventsAPI struct {
+		signingSecret string
+		delivered     *deliveredEvents
+		incoming      chan interface{}
+		now           func() time.Time
+	}
+
+	// eventsAPIEnvelope is the outer wrapper of every Events API request.
+	eventsAPIEnvelope struct {
+		Type      string          `json:"type"`
+		Challenge string          `json:"challenge"`
+		EventID   string          `json:"event_id"`
+		Event     json.RawMessage `json:"event"`
+	}
+
+	// deliveredEvents remembers the IDs of recently handled events.
+	deliveredEvents struct {
+		mu   sync.Mutex
+		seen map[string]time.Time
+	}
+)
+
+func newDeliveredEvents() *deliveredEvents {
+	return &deliveredEvents{seen: map[string]time.Time{}}
+}
+
+// firstDelivery records eventID as delivered at now, reporting whether it's
+// the first time it has been.
+func (d *deliveredEvents) firstDelivery(eventID string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if seen, ok := d.seen[eventID]; ok && now.Sub(seen) < eventRetention {
+		return false
+	}
+
+	for id, seen := range d.seen {
+		if now.Sub(seen) >= eventRetention {
+			delete(d.seen, id)
+		}
+	}
+
+	d.seen[eventID] = now
+
+	return true
+}
+
+// forget forgets eventID was delivered, so Slack's retry of it is handled.
+func (d *deliveredEvents) forget(eventID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.seen, eventID)
+}
+
+// decodeEvent decodes the inner event of an event callback into the same
+// types the RTM API delivers, or nil for events the bot doesn't handle.
+func decodeEvent(raw json.RawMessage) (interface{}, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var event interface{}
+
+	switch header.Type {
+	case "message":
+		event = &slack.MessageEvent{}
+	case "team_join":
+		event = &slack.TeamJoinEvent{}
+	case "user_change":
+		event = &slack.UserChangeEvent{}
+	case "reaction_added":
+		event = &slack.ReactionAddedEvent{}
+	case "member_joined_channel":
+		event = &slack.MemberJoinedChannelEvent{}
+	default:
+		return nil, nil
+	}
+
+	if err := json.Unmarshal(raw, event); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s event", header.Type)
+	}
+
+	return event, nil
+}
+
+// NewEventsAPI returns an Events API receiver verifying requests with signingSecret.
+func NewEventsAPI(signingSecret string) *EventsAPI {
+	return &EventsAPI{
+		signingSecret: signingSecret,
+		delivered:     newDeliveredEvents(),
+		incoming:      make(chan interface{}, 64),
+		now:           time.Now,
+	}
+}
+
+// Listen implements EventSource.
+func (e *EventsAPI) Listen(ctx context.Context, events chan<- interface{}) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-e.incoming:
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// ServeHTTP serves Slack Events API requests.
+func (e *EventsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := verifyRequest(r, e.signingSecret)
+	if err != nil {
+		log.Println("rejecting Events API request:", err)
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	var envelope eventsAPIEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	switch envelope.Type {
+	case eventsAPIURLVerification:
+		writeJSON(w, map[string]string{"challenge": envelope.Challenge})
+		return
+	case eventsAPICallback:
+	default:
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !e.delivered.firstDelivery(envelope.EventID, e.now()) {
+		log.Printf("ignoring retry #%s of event %s (%s)\n",
+			r.Header.Get("X-Slack-Retry-Num"), envelope.EventID, r.Header.Get("X-Slack-Retry-Reason"))
+
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	event, err := decodeEvent(envelope.Event)
+	if err != nil {
+		log.Printf("unable to decode event %s: %v\n", envelope.EventID, err)
+	}
+
+	if event != nil {
+		select {
+		case e.incoming <- event:
+		case <-r.Context().Done():
+			e.delivered.forget(envelope.EventID)
+			http.Error(w, "too busy", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,166 @@
+package mcdowell_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+)
+
+func eventsRequest(t *testing.T, secret, body string) *http.Request {
+	t.Helper()
+	return signedRequest(t, "/slack/events", secret, "application/json", body)
+}
+
+// listen starts source and returns the channel its events are delivered on.
+func listen(t *testing.T, source mcdowell.EventSource) <-chan interface{} {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	events := make(chan interface{}, 16)
+
+	go source.Listen(ctx, events)
+
+	return events
+}
+
+// nextEvent waits for the next event on events, or nil if none arrives soon.
+func nextEvent(t *testing.T, events <-chan interface{}) interface{} {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+func TestEventsAPIURLVerification(t *testing.T) {
+	api := mcdowell.NewEventsAPI(testSigningSecret)
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, eventsRequest(t, testSigningSecret, `{
+		"token": "Jhj5dZrVaK7ZwHHjRyZWjbDl",
+		"challenge": "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P",
+		"type": "url_verification"
+	}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var reply map[string]string
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &reply))
+	assert.Equal(t, "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P", reply["challenge"])
+
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, eventsRequest(t, "not the secret", `{"type": "url_verification", "challenge": "nope"}`))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestEventsAPIMessageCallbacks(t *testing.T) {
+	api := mcdowell.NewEventsAPI(testSigningSecret)
+	events := listen(t, api)
+
+	callback := `{
+		"type": "event_callback",
+		"event_id": "Ev0PV52K21",
+		"event": {
+			"type": "message",
+			"channel": "C0000GENERAL",
+			"user": "U0AKEEM",
+			"text": "They gonna have to show me the money!",
+			"ts": "1355517523.000005"
+		}
+	}`
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, eventsRequest(t, testSigningSecret, callback))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	message, ok := nextEvent(t, events).(*slack.MessageEvent)
+	assert.True(t, ok)
+	assert.Equal(t, "C0000GENERAL", message.Channel)
+	assert.Equal(t, "U0AKEEM", message.User)
+	assert.Equal(t, "They gonna have to show me the money!", message.Text)
+
+	r := eventsRequest(t, testSigningSecret, callback)
+	r.Header.Set("X-Slack-Retry-Num", "1")
+	r.Header.Set("X-Slack-Retry-Reason", "http_timeout")
+
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	select {
+	case event := <-events:
+		t.Fatalf("retries of delivered events should be ignored, got %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventsAPITeamJoinCallbacks(t *testing.T) {
+	api := mcdowell.NewEventsAPI(testSigningSecret)
+	events := listen(t, api)
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, eventsRequest(t, testSigningSecret, `{
+		"type": "event_callback",
+		"event_id": "Ev0PV52K22",
+		"event": {
+			"type": "team_join",
+			"user": {"id": "U0SEMMI", "name": "Semmi"}
+		}
+	}`))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	joined, ok := nextEvent(t, events).(*slack.TeamJoinEvent)
+	assert.True(t, ok)
+	assert.Equal(t, "U0SEMMI", joined.User.ID)
+	assert.Equal(t, "Semmi", joined.User.Name)
+}
+
+func TestEventsAPIRetriesEventsItWasTooBusyFor(t *testing.T) {
+	api := mcdowell.NewEventsAPI(testSigningSecret)
+
+	callback := func(id string) string {
+		return fmt.Sprintf(`{"type": "event_callback", "event_id": %q, "event": {"type": "message", "channel": "C0000GENERAL", "user": "U0AKEEM", "text": %q}}`, id, id)
+	}
+
+	// Nobody's listening, so the queue fills up.
+	for i := 0; i < 64; i++ {
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, eventsRequest(t, testSigningSecret, callback(fmt.Sprintf("Ev%d", i))))
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, eventsRequest(t, testSigningSecret, callback("Ev64")).WithContext(ctx))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	events := listen(t, api)
+
+	for i := 0; i < 64; i++ {
+		assert.NotNil(t, nextEvent(t, events))
+	}
+
+	// Slack's retry gets through.
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, eventsRequest(t, testSigningSecret, callback("Ev64")))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	message, ok := nextEvent(t, events).(*slack.MessageEvent)
+	assert.True(t, ok)
+	assert.Equal(t, "Ev64", message.Text)
+}
@@ -4,6 +4,7 @@ go 1.22.5
 
 require (
 	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/websocket v1.2.0
 	github.com/nlopes/slack v0.6.0
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.2.2
@@ -11,6 +12,5 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/gorilla/websocket v1.2.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 )
@@ -0,0 +1,45 @@
+package mcdowell
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Identity is who the bot is, as far as Slack is concerned.
+type Identity struct {
+	UserID string
+	BotID  string
+	Name   string
+	TeamID string
+	Team   string
+	URL    string
+}
+
+// Identity returns who the bot's token says it is.
+func (b *Bot) Identity() Identity {
+	return b.identity
+}
+
+// resolveIdentity asks Slack who the bot's token belongs to.
+func (b *Bot) resolveIdentity() error {
+	auth, err := b.client.AuthTest()
+	if err != nil {
+		return errors.Wrap(err, "unable to determine who the bot is")
+	}
+
+	b.identity = Identity{
+		UserID: auth.UserID,
+		Name:   auth.User,
+		TeamID: auth.TeamID,
+		Team:   auth.Team,
+		URL:    auth.URL,
+	}
+
+	user, err := b.client.GetUserInfo(auth.UserID)
+	if err != nil {
+		return errors.Wrapf(err, "unable to look up the bot user %s", auth.UserID)
+	}
+
+	b.identity.BotID = user.Profile.BotID
+
+	return nil
+}
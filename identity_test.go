@@ -0,0 +1,47 @@
+package mcdowell_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+func TestBotIdentity(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := slacktest.NewServer(t, map[string]string{
+		"auth.test":  `{"ok": true, "url": "https://zamunda.slack.com/", "team": "Zamunda", "user": "cleo", "team_id": "T0ZAMUNDA", "user_id": "U0CLEO"}`,
+		"users.info": `{"ok": true, "user": {"id": "U0CLEO", "name": "cleo", "is_bot": true, "profile": {"bot_id": "B0CLEO"}}}`,
+		"users.list": `{"ok": true, "members": [{"id": "U0WILL", "name": "willmadison"}]}`,
+	})
+
+	m, err := mcdowell.NewBot(ctx, fake.Client(), mcdowell.WithTesting(), mcdowell.Versioned("2.0.0"), slacktest.WithConfig(t, `{"roster": {"admins": ["willmadison"]}}`))
+	assert.Nil(t, err)
+
+	assert.Equal(t, mcdowell.Identity{
+		UserID: "U0CLEO",
+		BotID:  "B0CLEO",
+		Name:   "cleo",
+		TeamID: "T0ZAMUNDA",
+		Team:   "Zamunda",
+		URL:    "https://zamunda.slack.com/",
+	}, m.Identity())
+
+	assert.Equal(t, "U0CLEO", fake.Calls("users.info")[0].Form.Get("user"))
+	assert.Equal(t, "sucessfully deployed cleo v2.0.0...", fake.Last().Form.Get("text"))
+}
+
+func TestBotWithABadTokenDoesNotStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := slacktest.NewServer(t, map[string]string{
+		"auth.test": `{"ok": false, "error": "invalid_auth"}`,
+	})
+
+	_, err := mcdowell.NewBot(ctx, fake.Client(), mcdowell.WithTesting())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid_auth")
+}
@@ -0,0 +1,116 @@
+package mcdowell
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// SubmissionErrors rejects a modal submission, giving the problem with each
+// field by block ID. Handlers return it to have Slack show the problems next
+// to the fields rather than close the modal.
+type SubmissionErrors map[string]string
+
+func (e SubmissionErrors) Error() string {
+	var problems []string
+	for name, problem := range e {
+		problems = append(problems, name+": "+problem)
+	}
+
+	sort.Strings(problems)
+
+	return "invalid submission: " + strings.Join(problems, ", ")
+}
+
+// HandleInteraction serves Slack interactivity requests, sent when people
+// click buttons in the bot's messages or submit its modals. Modal
+// submissions are handled before replying so any problems with them can be
+// shown.
+func (b *Bot) HandleInteraction(w http.ResponseWriter, r *http.Request) {
+	if _, err := verifyRequest(r, b.signingSecret); err != nil {
+		log.Println("rejecting interaction:", err)
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid interaction", http.StatusBadRequest)
+		return
+	}
+
+	interaction, err := decodeInteraction([]byte(r.PostForm.Get("payload")))
+	if err != nil {
+		http.Error(w, "invalid interaction payload", http.StatusBadRequest)
+		return
+	}
+
+	if submission, ok := interaction.(*ViewSubmission); ok {
+		b.submitView(w, submission)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	b.dispatch(interaction)
+}
+
+// decodeInteraction decodes an interaction payload into a *ViewSubmission
+// for modal submissions, or a *slack.InteractionCallback otherwise.
+func decodeInteraction(payload []byte) (interface{}, error) {
+	var kind struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(payload, &kind); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if kind.Type == viewSubmissionType {
+		var submission ViewSubmission
+		if err := json.Unmarshal(payload, &submission); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return &submission, nil
+	}
+
+	var callback slack.InteractionCallback
+	if err := json.Unmarshal(payload, &callback); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return &callback, nil
+}
+
+// submitView handles a modal submission, replying with any problems with it
+// or an empty response to close the modal.
+func (b *Bot) submitView(w http.ResponseWriter, submission *ViewSubmission) {
+	if problems := b.checkSubmission(submission); problems != nil {
+		writeJSON(w, problems)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkSubmission handles a modal submission, returning the problems with
+// it if a handler rejected it.
+func (b *Bot) checkSubmission(submission *ViewSubmission) *viewErrors {
+	err := b.HandleEvent(submission)
+
+	invalid, ok := errors.Cause(err).(SubmissionErrors)
+	if !ok {
+		if err != nil {
+			log.Printf("failed to handle %s submission: %v\n", submission.View.CallbackID, err)
+		}
+
+		return nil
+	}
+
+	return &viewErrors{ResponseAction: "errors", Errors: invalid}
+}
@@ -0,0 +1,302 @@
+// Package slacktest runs a fake Slack Web API for testing bots and plugins.
+package slacktest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/willmadison/mcdowell"
+)
+
+// Admin is the ID of willmadison, the admin of bots started with NewBot
+// unless their config has a roster, and in the fake Slack's directory unless
+// a test replaces users.list.
+const Admin = "U0WILL"
+
+// Start is when the clocks of bots started with NewBot start.
+var Start = time.Date(2019, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+// IdentityResponses are what the fake Slack says about the bot.
+var IdentityResponses = map[string]string{
+	"auth.test":  `{"ok": true, "url": "https://atlblacktech.slack.com/", "team": "ATL Black Tech", "user": "mcdowell", "team_id": "T0ATLBT", "user_id": "U0MCDOWELL"}`,
+	"users.info": `{"ok": true, "user": {"id": "U0MCDOWELL", "name": "mcdowell", "is_bot": true, "profile": {"bot_id": "B0MCDOWELL"}}}`,
+}
+
+// directoryResponse is the fake Slack's users.list unless told otherwise.
+const directoryResponse = `{"ok": true, "members": [{"id": "U0WILL", "name": "willmadison"}]}`
+
+// okResponse is the reply to any other method, good enough for chat.postMessage.
+const okResponse = `{"ok": true, "channel": "C123", "ts": "123.456", "message": {}}`
+
+type (
+	// Server is a fake Slack Web API recording every call made to it.
+	Server struct {
+		*httptest.Server
+
+		mu        sync.Mutex
+		calls     []Call
+		responses map[string]string
+		limited   map[string]int
+	}
+
+	// Call is a single Web API call, e.g. to "chat.postMessage". Body is
+	// the raw request, for methods like views.open that are sent JSON.
+	Call struct {
+		Method string
+		Form   url.Values
+		Body   []byte
+	}
+
+	// Clock is a clock tests move by hand.
+	Clock struct {
+		mu  sync.Mutex
+		now time.Time
+	}
+)
+
+// NewServer starts a fake Slack replying to each method with its response in
+// responses, keyed by method name. Later pages of paginated methods are keyed
+// by method and cursor, e.g. "users.list?cursor=page2". It's closed when t
+// finishes.
+func NewServer(t testing.TB, responses map[string]string) *Server {
+	s := &Server{responses: map[string]string{"users.list": directoryResponse}, limited: map[string]int{}}
+
+	for method, response := range IdentityResponses {
+		s.responses[method] = response
+	}
+
+	for method, response := range responses {
+		s.responses[method] = response
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/")
+
+	body, _ := io.ReadAll(r.Body)
+	form, _ := url.ParseQuery(string(body))
+
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Form: form, Body: body})
+
+	limited := s.limited[method] > 0
+	if limited {
+		s.limited[method]--
+	}
+
+	response, ok := s.responses[method]
+	if cursor := form.Get("cursor"); cursor != "" {
+		response, ok = s.responses[method+"?cursor="+cursor]
+	}
+	s.mu.Unlock()
+
+	if limited {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	if !ok {
+		response = okResponse
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(response))
+}
+
+// RateLimit turns the next n calls to method away, asking the caller to
+// retry straight away.
+func (s *Server) RateLimit(method string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.limited[method] += n
+}
+
+// Client returns a Slack client talking to the fake.
+func (s *Server) Client() *slack.Client {
+	return slack.New("dummyToken", slack.OptionAPIURL(s.URL+"/"))
+}
+
+// Calls returns every call made to method so far.
+func (s *Server) Calls(method string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var calls []Call
+	for _, call := range s.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// Messages returns the text of every message posted so far.
+func (s *Server) Messages() []string {
+	var messages []string
+	for _, call := range s.Calls("chat.postMessage") {
+		messages = append(messages, call.Form.Get("text"))
+	}
+
+	return messages
+}
+
+// All returns every call made so far, in order.
+func (s *Server) All() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Call(nil), s.calls...)
+}
+
+// Methods returns the method of every call made so far, in order.
+func (s *Server) Methods() []string {
+	var methods []string
+	for _, call := range s.All() {
+		methods = append(methods, call.Method)
+	}
+
+	return methods
+}
+
+// Last returns the most recent call, or nothing if there hasn't been one.
+func (s *Server) Last() Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.calls) == 0 {
+		return Call{}
+	}
+
+	return s.calls[len(s.calls)-1]
+}
+
+// Reset forgets every call made so far.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = nil
+}
+
+// NewBot starts a bot configured by config, if it isn't empty, talking to a
+// fake Slack replying to each method with its response in responses. Admin
+// is on its roster unless config has one. The bot's clock starts at Start,
+// and the calls it made starting up are forgotten. options, e.g.
+// mcdowell.WithPlugin, are applied last, so they can replace any of these.
+func NewBot(t *testing.T, config string, responses map[string]string, options ...func(*mcdowell.Bot)) (*mcdowell.Bot, *Server, *Clock) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	s := NewServer(t, responses)
+	clock := NewClock(Start)
+
+	if config == "" {
+		config = "{}"
+	}
+
+	parsed := parseConfig(t, config)
+	if parsed.Roster == nil {
+		parsed.Roster = &mcdowell.RosterConfig{Admins: []string{"willmadison"}}
+	}
+
+	defaults := []func(*mcdowell.Bot){
+		mcdowell.WithTesting(),
+		mcdowell.WithConfig(parsed),
+		mcdowell.WithClock(clock.Now),
+		mcdowell.WithAPIToken("dummyToken"),
+		mcdowell.WithAPIURL(s.URL + "/"),
+	}
+
+	b, err := mcdowell.NewBot(ctx, s.Client(), append(defaults, options...)...)
+	if err != nil {
+		t.Fatalf("unable to start the bot: %v", err)
+	}
+
+	s.Reset()
+
+	return b, s, clock
+}
+
+// WithConfig configures a bot with config, failing t if it's invalid.
+func WithConfig(t testing.TB, config string) func(*mcdowell.Bot) {
+	t.Helper()
+
+	return mcdowell.WithConfig(parseConfig(t, config))
+}
+
+func parseConfig(t testing.TB, config string) *mcdowell.Config {
+	t.Helper()
+
+	parsed, err := mcdowell.ParseConfig(strings.NewReader(config))
+	if err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	return parsed
+}
+
+// Run runs command for userID, as if they'd run the slash command in
+// #general, and returns the reply.
+func Run(t *testing.T, b *mcdowell.Bot, userID, command string, args ...string) string {
+	t.Helper()
+
+	reply, err := b.RunCommand(&mcdowell.CommandRequest{
+		Command:   command,
+		Args:      args,
+		UserID:    userID,
+		ChannelID: "C0000GENERAL",
+		TriggerID: "12345.98765",
+	})
+	if err != nil {
+		t.Errorf("%s %s failed: %v", command, strings.Join(args, " "), err)
+	}
+
+	return reply
+}
+
+// NewClock returns a clock set to now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the time on the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Set moves the clock to now.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
+
+// Add moves the clock on by d.
+func (c *Clock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,273 @@
+package mcdowell
+
+import (
+	"log"
+	"text/template"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// Default introduction copy, rendered with a Welcome.
+const (
+	DefaultIntroductionAnnouncement = `Please welcome {{.Mention}} to the Atlanta Black Tech Family! :wave:`
+	DefaultIntroductionPrompt       = `{{.Mention}}, tell us a bit about yourself right here in this thread: what you do, what you’re working on and what you’re hoping to get out of the community.`
+	DefaultIntroductionReminder     = `Hey {{.DisplayName}}, we’d love to get to know you! When you get a chance, introduce yourself in {{.Introductions}}.`
+)
+
+// DefaultIntroductionReminderDelay is how long newcomers have to introduce
+// themselves before they're reminded.
+const DefaultIntroductionReminderDelay = 48 * time.Hour
+
+// introductionReminderInterval is how often overdue introductions are checked for.
+const introductionReminderInterval = time.Minute
+
+type (
+	// IntroductionsConfig has the bot welcome newcomers publicly in Channel,
+	// prompt them to introduce themselves in a thread and remind them by DM if
+	// they haven't posted there after RemindAfter. Messages are text/templates
+	// rendered with a Welcome.
+	IntroductionsConfig struct {
+		Channel      string   `json:"channel"`
+		Announcement string   `json:"announcement,omitempty"`
+		Prompt       string   `json:"prompt,omitempty"`
+		Reminder     string   `json:"reminder,omitempty"`
+		RemindAfter  Duration `json:"remind_after,omitempty"`
+	}
+
+	introductionTemplates struct {
+		announcement *template.Template
+		prompt       *template.Template
+		reminder     *template.Template
+	}
+
+	// pendingIntroduction is a newcomer who hasn't introduced themselves yet
+	// and when they're due a reminder.
+	pendingIntroduction struct {
+		User slack.User `json:"user"`
+		Due  time.Time  `json:"due"`
+	}
+)
+
+// introductionsBucket is where pending introductions are stored, keyed by user ID.
+const introductionsBucket = "introductions"
+
+func (c *IntroductionsConfig) validate() error {
+	if c.Channel == "" {
+		return errors.New("no channel")
+	}
+
+	_, err := c.templates()
+
+	return err
+}
+
+func (c *IntroductionsConfig) templates() (*introductionTemplates, error) {
+	var (
+		templates introductionTemplates
+		err       error
+	)
+
+	templates.announcement, err = parseTemplate("announcement", c.Announcement, DefaultIntroductionAnnouncement)
+	if err != nil {
+		return nil, err
+	}
+
+	templates.prompt, err = parseTemplate("prompt", c.Prompt, DefaultIntroductionPrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	templates.reminder, err = parseTemplate("reminder", c.Reminder, DefaultIntroductionReminder)
+	if err != nil {
+		return nil, err
+	}
+
+	return &templates, nil
+}
+
+func (c *IntroductionsConfig) remindAfter() time.Duration {
+	if c.RemindAfter.Duration == 0 {
+		return DefaultIntroductionReminderDelay
+	}
+
+	return c.RemindAfter.Duration
+}
+
+func (b *Bot) introductions() *IntroductionsConfig {
+	if b.config == nil {
+		return nil
+	}
+
+	return b.config.Introductions
+}
+
+// resolveIntroductionsChannel looks up the ID of the introductions channel.
+func (b *Bot) resolveIntroductionsChannel(ids map[string]string) error {
+	introductions := b.introductions()
+	if introductions == nil {
+		return nil
+	}
+
+	id, ok := resolveChannel(introductions.Channel, ids)
+	if !ok {
+		return errors.Errorf("unable to resolve the introductions channel %s", introductions.Channel)
+	}
+
+	b.introductionsChannel = FeaturedChannel{ID: id}
+	if isChannelName(introductions.Channel) {
+		b.introductionsChannel.Name = normalizeChannelName(introductions.Channel)
+	}
+
+	return nil
+}
+
+// introduce welcomes user in the introductions channel and prompts them to
+// introduce themselves in a thread.
+func (b *Bot) introduce(user slack.User) error {
+	if b.introductionTemplates == nil {
+		return nil
+	}
+
+	data := b.welcomeData(user)
+
+	announcement, err := render(b.introductionTemplates.announcement, data)
+	if err != nil {
+		return err
+	}
+
+	_, ts, err := b.client.PostMessage(b.introductionsChannel.ID,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(announcement, false),
+		slack.MsgOptionPostMessageParameters(slack.PostMessageParameters{
+			LinkNames: 1,
+		}),
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = putJSON(b.store, introductionsBucket, user.ID, pendingIntroduction{
+		User: user,
+		Due:  b.now().Add(b.introductions().remindAfter()),
+	})
+	if err != nil {
+		log.Printf("unable to schedule a reminder for %s to introduce themselves: %v\n", user.ID, err)
+	}
+
+	prompt, err := render(b.introductionTemplates.prompt, data)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = b.client.PostMessage(b.introductionsChannel.ID,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(prompt, false),
+		slack.MsgOptionTS(ts),
+	)
+
+	return errors.WithStack(err)
+}
+
+// SendIntroductionReminders DMs every newcomer who still hasn't introduced
+// themselves once their reminder is due. Each newcomer is reminded once.
+func (b *Bot) SendIntroductionReminders() error {
+	if b.introductionTemplates == nil {
+		return nil
+	}
+
+	overdue, err := b.overdueIntroductions()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+
+	for _, user := range overdue {
+		err := b.remindToIntroduce(user)
+		if err != nil {
+			log.Printf("unable to remind %s to introduce themselves: %v\n", user.ID, err)
+
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+func (b *Bot) remindToIntroduce(user slack.User) error {
+	reminder, err := render(b.introductionTemplates.reminder, b.welcomeData(user))
+	if err != nil {
+		return err
+	}
+
+	_, _, err = b.client.PostMessage(user.ID,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(reminder, false),
+	)
+
+	return errors.WithStack(err)
+}
+
+// watchIntroductions sends introduction reminders as they come due until the
+// bot's context is done.
+func (b *Bot) watchIntroductions() {
+	ticker := time.NewTicker(introductionReminderInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			b.SendIntroductionReminders()
+		}
+	}
+}
+
+// introduced records that userID has posted in the introductions channel.
+func (b *Bot) introduced(userID string) {
+	if err := b.store.Delete(introductionsBucket, userID); err != nil {
+		log.Printf("unable to record that %s introduced themselves: %v\n", userID, err)
+	}
+}
+
+// overdueIntroductions removes and returns everyone whose reminder is due.
+func (b *Bot) overdueIntroductions() ([]slack.User, error) {
+	userIDs, err := b.store.Keys(introductionsBucket)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	now := b.now()
+
+	var users []slack.User
+
+	for _, userID := range userIDs {
+		var pending pendingIntroduction
+
+		err := getJSON(b.store, introductionsBucket, userID, &pending)
+		if err == ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if pending.Due.After(now) {
+			continue
+		}
+
+		if err := b.store.Delete(introductionsBucket, userID); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		users = append(users, pending.User)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,114 @@
+package mcdowell_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+const introductionsConfig = `{"introductions": {"channel": "#introductions"}}`
+
+var introductionsChannel = map[string]string{
+	"conversations.list": `{"ok": true, "channels": [{"id": "C00000INTRO", "name": "introductions"}]}`,
+}
+
+func TestNewcomersAreIntroducedPublicly(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, introductionsConfig, introductionsChannel)
+
+	err := m.OnTeamJoined(&slack.TeamJoinEvent{
+		User: slack.User{ID: "U0SEMMI", Name: "semmi", Profile: slack.UserProfile{DisplayName: "Semmi"}},
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"chat.postMessage", "chat.postMessage", "chat.postMessage"}, fake.Methods())
+
+	assert.Equal(t, "U0SEMMI", fake.All()[0].Form.Get("channel"))
+
+	announcement := fake.All()[1].Form
+	assert.Equal(t, "C00000INTRO", announcement.Get("channel"))
+	assert.Equal(t, "Please welcome <@U0SEMMI> to the Atlanta Black Tech Family! :wave:", announcement.Get("text"))
+	assert.Equal(t, "1", announcement.Get("link_names"))
+
+	prompt := fake.All()[2].Form
+	assert.Equal(t, "C00000INTRO", prompt.Get("channel"))
+	assert.Equal(t, "123.456", prompt.Get("thread_ts"))
+	assert.True(t, strings.HasPrefix(prompt.Get("text"), "<@U0SEMMI>, tell us a bit about yourself"))
+}
+
+func TestNewcomersAreRemindedToIntroduceThemselves(t *testing.T) {
+	m, fake, clock := slacktest.NewBot(t, introductionsConfig, introductionsChannel)
+
+	for _, user := range []slack.User{
+		{ID: "U0SEMMI", Name: "semmi", Profile: slack.UserProfile{DisplayName: "Semmi"}},
+		{ID: "U0AKEEM", Name: "akeem"},
+	} {
+		err := m.OnTeamJoined(&slack.TeamJoinEvent{User: user})
+		assert.Nil(t, err)
+	}
+
+	err := m.OnNewMessage(&slack.MessageEvent{Msg: slack.Msg{
+		Channel:         "C00000INTRO",
+		User:            "U0AKEEM",
+		Text:            "Hi y'all, I'm a prince from Zamunda.",
+		ThreadTimestamp: "123.456",
+	}})
+	assert.Nil(t, err)
+
+	fake.Reset()
+
+	clock.Add(47 * time.Hour)
+	err = m.SendIntroductionReminders()
+	assert.Nil(t, err)
+	assert.Empty(t, fake.All())
+
+	clock.Add(time.Hour)
+	err = m.SendIntroductionReminders()
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"chat.postMessage"}, fake.Methods())
+	assert.Equal(t, "U0SEMMI", fake.Last().Form.Get("channel"))
+	assert.Equal(t, "Hey Semmi, we’d love to get to know you! When you get a chance, introduce yourself in <#C00000INTRO>.", fake.Last().Form.Get("text"))
+
+	fake.Reset()
+
+	clock.Add(48 * time.Hour)
+	err = m.SendIntroductionReminders()
+	assert.Nil(t, err)
+	assert.Empty(t, fake.All())
+}
+
+func TestIntroductionRemindersSurviveRestarts(t *testing.T) {
+	store := mcdowell.NewMemoryStore()
+
+	m, _, _ := slacktest.NewBot(t, introductionsConfig, introductionsChannel, mcdowell.WithStore(store))
+
+	err := m.OnTeamJoined(&slack.TeamJoinEvent{
+		User: slack.User{ID: "U0SEMMI", Name: "semmi", Profile: slack.UserProfile{DisplayName: "Semmi"}},
+	})
+	assert.Nil(t, err)
+
+	restarted, fake, clock := slacktest.NewBot(t, introductionsConfig, introductionsChannel, mcdowell.WithStore(store))
+
+	clock.Add(48 * time.Hour)
+	err = restarted.SendIntroductionReminders()
+	assert.Nil(t, err)
+
+	assert.Equal(t, "U0SEMMI", fake.Last().Form.Get("channel"))
+	assert.Contains(t, fake.Last().Form.Get("text"), "Hey Semmi")
+}
+
+func TestInvalidIntroductionsConfigIsRejected(t *testing.T) {
+	for _, config := range []string{
+		`{"introductions": {}}`,
+		`{"introductions": {"channel": "#introductions", "prompt": "{{.Mention"}}`,
+		`{"introductions": {"channel": "#introductions", "remind_after": "-1h"}}`,
+	} {
+		_, err := mcdowell.ParseConfig(strings.NewReader(config))
+		assert.NotNil(t, err, config)
+	}
+}
@@ -0,0 +1,599 @@
+// Package jobs is a job board: members post openings through a modal form
+// with "/mcdowell job post", the bot shares each one as a uniform card in the
+// jobs channel, and "mcdowell jobs remote golang" searches what's still open.
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+	"github.com/willmadison/mcdowell"
+)
+
+// Name is the job board plugin's name, and its section under "plugins" in
+// the bot's config.
+const Name = "jobs"
+
+// DefaultChannel is where postings are shared unless configured otherwise.
+const DefaultChannel = "#jobs"
+
+// DefaultExpiry is how long postings stay up unless configured otherwise.
+const DefaultExpiry = 30 * 24 * time.Hour
+
+// postingsBucket is where postings are stored, keyed by ID.
+const postingsBucket = "postings"
+
+// postCallback identifies submissions of the job posting form.
+const postCallback = "jobs:post"
+
+// openFormAction identifies clicks on the button that opens the form.
+const openFormAction = "jobs:open_form"
+
+// expiryJob is the scheduled job that takes down expired postings.
+const expiryJob = "jobs:expire"
+
+// maxListed is how many postings "jobs" lists.
+const maxListed = 10
+
+// Ways of working a posting can offer.
+const (
+	Remote = "remote"
+	Hybrid = "hybrid"
+	Onsite = "onsite"
+)
+
+var workplaces = []*mcdowell.InputOption{
+	mcdowell.NewInputOption(Remote, "Remote"),
+	mcdowell.NewInputOption(Hybrid, "Hybrid"),
+	mcdowell.NewInputOption(Onsite, "On-site"),
+}
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+type (
+	// Config is the job board's section of the bot's config.
+	Config struct {
+		Channel     string            `json:"channel,omitempty"`
+		ExpireAfter mcdowell.Duration `json:"expire_after,omitempty"`
+	}
+
+	// Posting is a job opening a member shared.
+	Posting struct {
+		ID        string    `json:"id"`
+		Title     string    `json:"title"`
+		Company   string    `json:"company"`
+		Location  string    `json:"location,omitempty"`
+		Workplace string    `json:"workplace"`
+		Salary    string    `json:"salary,omitempty"`
+		Link      string    `json:"link"`
+		Tags      []string  `json:"tags,omitempty"`
+		PostedBy  string    `json:"posted_by"`
+		Posted    time.Time `json:"posted"`
+		Expires   time.Time `json:"expires"`
+
+		// Channel and Timestamp are where the posting's card is.
+		Channel   string `json:"channel,omitempty"`
+		Timestamp string `json:"ts,omitempty"`
+	}
+
+	// Board is the job board plugin.
+	Board struct {
+		channel     string
+		expireAfter time.Duration
+
+		// mu keeps postings made at once from taking the same ID.
+		mu sync.Mutex
+	}
+)
+
+// New creates the job board plugin.
+func New() *Board {
+	return &Board{}
+}
+
+// Name implements mcdowell.Plugin.
+func (j *Board) Name() string {
+	return Name
+}
+
+// Init implements mcdowell.Plugin.
+func (j *Board) Init(b *mcdowell.Bot) error {
+	var config Config
+
+	err := b.PluginConfig(Name, &config)
+	if err != nil && err != mcdowell.ErrNotFound {
+		return err
+	}
+
+	channel := config.Channel
+	if channel == "" {
+		channel = DefaultChannel
+	}
+
+	j.channel, err = b.ResolveChannel(channel)
+	if err != nil && config.Channel == "" {
+		log.Printf("unable to find %s, the job board is disabled\n", DefaultChannel)
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to resolve the jobs channel")
+	}
+
+	j.expireAfter = config.ExpireAfter.Duration
+	if j.expireAfter <= 0 {
+		j.expireAfter = DefaultExpiry
+	}
+
+	err = b.RegisterJob(mcdowell.Job{Name: expiryJob, Schedule: "@hourly", Run: j.expire})
+	if err != nil {
+		return err
+	}
+
+	err = b.RegisterCommand(mcdowell.Command{
+		Name:        "job",
+		Usage:       "job post | job remove id",
+		Description: "Shares a job opening in the jobs channel, or takes one of yours down.",
+		Handler:     j.command,
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.RegisterCommand(mcdowell.Command{
+		Name:        "jobs",
+		Usage:       "jobs [search terms]",
+		Description: "Lists open jobs, e.g. `jobs remote golang`.",
+		Handler:     j.search,
+	})
+}
+
+// Handlers implements mcdowell.Plugin.
+func (j *Board) Handlers() map[string]mcdowell.Handler {
+	return map[string]mcdowell.Handler{
+		mcdowell.EventInteraction:    j.onInteraction,
+		mcdowell.EventViewSubmission: j.onSubmission,
+	}
+}
+
+func (j *Board) command(b *mcdowell.Bot, req *mcdowell.CommandRequest) (string, error) {
+	if len(req.Args) == 0 {
+		return "Usage: job post | job remove id", nil
+	}
+
+	switch strings.ToLower(req.Args[0]) {
+	case "post":
+		return j.openForm(b, req)
+	case "remove":
+		return j.remove(b, req)
+	default:
+		return "Sorry, I don't know how to do that with jobs. Try `help job`.", nil
+	}
+}
+
+// openForm shows the job posting form to whoever asked for it. Commands
+// sent in messages can't open it, so they get a button that does.
+func (j *Board) openForm(b *mcdowell.Bot, req *mcdowell.CommandRequest) (string, error) {
+	if req.TriggerID == "" {
+		_, _, err := b.Client().PostMessage(req.ChannelID,
+			slack.MsgOptionAsUser(true),
+			slack.MsgOptionText("Tell me about the job and I’ll share it in the jobs channel.", false),
+			slack.MsgOptionBlocks(
+				slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, "Tell me about the job and I’ll share it in the jobs channel.", false, false), nil, nil),
+				slack.NewActionBlock("",
+					slack.NewButtonBlockElement(openFormAction, "", slack.NewTextBlockObject(slack.PlainTextType, "Post a job", false, false)),
+				),
+			),
+		)
+
+		return "", errors.Wrap(err, "unable to offer the job posting form")
+	}
+
+	if err := b.OpenView(req.TriggerID, form()); err != nil {
+		return "", errors.Wrap(err, "unable to open the job posting form")
+	}
+
+	return "Tell me about the job and I’ll share it in the jobs channel.", nil
+}
+
+// form is the job posting form.
+func form() mcdowell.ModalView {
+	location := mcdowell.NewInputBlock("location", "Location", mcdowell.NewTextInput("Atlanta, GA"))
+	location.Optional = true
+
+	workplace := mcdowell.NewSelectInput("Remote?", workplaces...)
+	workplace.InitialOption = workplaces[0]
+
+	salary := mcdowell.NewInputBlock("salary", "Salary range", mcdowell.NewTextInput("$120k - $150k"))
+	salary.Optional = true
+
+	tags := mcdowell.NewInputBlock("tags", "Skills", mcdowell.NewTextInput("golang, react, devops"))
+	tags.Optional = true
+	tags.Hint = slack.NewTextBlockObject(slack.PlainTextType, "Separate skills with commas so people can find the job.", false, false)
+
+	return mcdowell.NewModalView(postCallback, "Post a job", "Post",
+		mcdowell.NewInputBlock("title", "Job title", mcdowell.NewTextInput("")),
+		mcdowell.NewInputBlock("company", "Company", mcdowell.NewTextInput("")),
+		location,
+		mcdowell.NewInputBlock("workplace", "Remote?", workplace),
+		salary,
+		mcdowell.NewInputBlock("link", "Link", mcdowell.NewTextInput("https://")),
+		tags,
+	)
+}
+
+// onInteraction opens the job posting form when someone clicks the button offering it.
+func (j *Board) onInteraction(b *mcdowell.Bot, event interface{}) error {
+	callback, ok := event.(*slack.InteractionCallback)
+	if !ok || callback.Type != slack.InteractionTypeBlockActions {
+		return nil
+	}
+
+	for _, action := range callback.ActionCallback.BlockActions {
+		if action.ActionID == openFormAction {
+			return errors.Wrap(b.OpenView(callback.TriggerID, form()), "unable to open the job posting form")
+		}
+	}
+
+	return nil
+}
+
+// onSubmission shares a submitted posting and lets whoever submitted it know.
+func (j *Board) onSubmission(b *mcdowell.Bot, event interface{}) error {
+	submission, ok := event.(*mcdowell.ViewSubmission)
+	if !ok || submission.View.CallbackID != postCallback {
+		return nil
+	}
+
+	p, err := j.parseSubmission(b, submission.User.ID, submission.Values())
+	if err != nil {
+		return err
+	}
+
+	if err := j.post(b, &p); err != nil {
+		return err
+	}
+
+	_, _, err = b.Client().PostMessage(submission.User.ID,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(fmt.Sprintf("Thanks! Your posting is up in <#%s>. It comes down in %d days, or sooner with `job remove %s`.", j.channel, int(j.expireAfter.Hours()/24), p.ID), false),
+	)
+
+	return errors.WithStack(err)
+}
+
+// parseSubmission turns the values submitted in the job posting form into a Posting.
+func (j *Board) parseSubmission(b *mcdowell.Bot, user string, values map[string]string) (Posting, error) {
+	field := func(name string) string {
+		return strings.TrimSpace(values[name])
+	}
+
+	now := b.Now()
+
+	p := Posting{
+		Title:     field("title"),
+		Company:   field("company"),
+		Location:  field("location"),
+		Workplace: field("workplace"),
+		Salary:    field("salary"),
+		Link:      field("link"),
+		Tags:      splitTags(field("tags")),
+		PostedBy:  user,
+		Posted:    now,
+		Expires:   now.Add(j.expireAfter),
+	}
+
+	problems := mcdowell.SubmissionErrors{}
+
+	if p.Title == "" {
+		problems["title"] = "What’s the job called?"
+	}
+
+	if p.Company == "" {
+		problems["company"] = "Who’s hiring?"
+	}
+
+	switch p.Workplace {
+	case Remote, Hybrid, Onsite:
+	default:
+		problems["workplace"] = "Pick remote, hybrid or on-site."
+	}
+
+	if link, err := url.Parse(p.Link); err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+		problems["link"] = "Enter a link to the posting, starting with https://"
+	}
+
+	if len(problems) > 0 {
+		return p, problems
+	}
+
+	return p, nil
+}
+
+func splitTags(tags string) []string {
+	var split []string
+
+	for _, tag := range strings.FieldsFunc(tags, func(r rune) bool { return r == ',' || r == ';' }) {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			split = append(split, tag)
+		}
+	}
+
+	return split
+}
+
+// post shares p in the jobs channel and stores it under a new ID.
+func (j *Board) post(b *mcdowell.Bot, p *Posting) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var err error
+
+	p.ID, err = j.newID(b, *p)
+	if err != nil {
+		return err
+	}
+
+	p.Channel = j.channel
+
+	_, p.Timestamp, err = b.Client().PostMessage(j.channel,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(fmt.Sprintf("%s at %s: %s", p.Title, p.Company, p.Link), false),
+		slack.MsgOptionBlocks(card(*p)...),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "unable to share %s", p.ID)
+	}
+
+	return put(b.Store(), *p)
+}
+
+// newID derives a readable ID for p from its company and title, e.g.
+// "mcdowells-fry-cook", numbered if it's already taken.
+func (j *Board) newID(b *mcdowell.Bot, p Posting) (string, error) {
+	base := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(p.Company+" "+p.Title), "-"), "-")
+
+	id := base
+	for n := 2; ; n++ {
+		_, err := b.Store().Get(postingsBucket, id)
+		if err == mcdowell.ErrNotFound {
+			return id, nil
+		}
+
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// card is how every posting looks in the jobs channel.
+func card(p Posting) []slack.Block {
+	details := []string{p.Company, workplaceLabel(p.Workplace)}
+	if p.Location != "" {
+		details = append(details, p.Location)
+	}
+
+	text := fmt.Sprintf("*<%s|%s>*\n%s", p.Link, p.Title, strings.Join(details, " · "))
+
+	if p.Salary != "" {
+		text += "\n:moneybag: " + p.Salary
+	}
+
+	if len(p.Tags) > 0 {
+		text += "\n:hammer_and_wrench: " + strings.Join(p.Tags, ", ")
+	}
+
+	return []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, text, false, false), nil, nil),
+		slack.NewContextBlock("",
+			slack.NewTextBlockObject(slack.MarkdownType, fmt.Sprintf("Posted by <@%s> · Open until %s · `%s`", p.PostedBy, p.Expires.Format("January 2"), p.ID), false, false),
+		),
+	}
+}
+
+func workplaceLabel(workplace string) string {
+	for _, w := range workplaces {
+		if w.Value == workplace {
+			return w.Text.Text
+		}
+	}
+
+	return workplace
+}
+
+// describe formats p for a list, e.g. "*<link|Fry Cook>* at McDowell's
+// (On-site, Queens, NY) - $15/hr".
+func describe(p Posting) string {
+	details := workplaceLabel(p.Workplace)
+	if p.Location != "" {
+		details += ", " + p.Location
+	}
+
+	text := fmt.Sprintf("*<%s|%s>* at %s (%s)", p.Link, p.Title, p.Company, details)
+
+	if p.Salary != "" {
+		text += " - " + p.Salary
+	}
+
+	return text
+}
+
+// search lists the open postings matching every search term, newest first.
+func (j *Board) search(b *mcdowell.Bot, req *mcdowell.CommandRequest) (string, error) {
+	postings, err := Postings(b.Store())
+	if err != nil {
+		return "", err
+	}
+
+	now := b.Now()
+
+	var matches []Posting
+	for _, p := range postings {
+		if p.Expires.After(now) && p.matches(req.Args) {
+			matches = append(matches, p)
+		}
+	}
+
+	if len(matches) == 0 {
+		if len(req.Args) == 0 {
+			return "No open jobs right now. Know of one? Share it with `/mcdowell job post`.", nil
+		}
+
+		return fmt.Sprintf("No open jobs match %q.", strings.Join(req.Args, " ")), nil
+	}
+
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "*Open jobs* (%d)\n", len(matches))
+
+	for i, p := range matches {
+		if i == maxListed {
+			fmt.Fprintf(&text, "…and %d more. Try narrowing your search.\n", len(matches)-maxListed)
+			break
+		}
+
+		fmt.Fprintf(&text, "• %s\n", describe(p))
+	}
+
+	return strings.TrimSuffix(text.String(), "\n"), nil
+}
+
+// matches reports whether every term appears somewhere in p.
+func (p Posting) matches(terms []string) bool {
+	haystack := strings.ToLower(strings.Join(append([]string{
+		p.Title, p.Company, p.Location, p.Workplace, workplaceLabel(p.Workplace), p.Salary,
+	}, p.Tags...), " "))
+
+	for _, term := range terms {
+		if !strings.Contains(haystack, strings.ToLower(term)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// remove takes a posting down at the request of whoever posted it or an admin.
+func (j *Board) remove(b *mcdowell.Bot, req *mcdowell.CommandRequest) (string, error) {
+	if len(req.Args) != 2 {
+		return "Usage: job remove id", nil
+	}
+
+	id := req.Args[1]
+
+	p, err := get(b.Store(), id)
+	if err == mcdowell.ErrNotFound {
+		return fmt.Sprintf("Sorry, there's no job `%s`.", id), nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if p.PostedBy != req.UserID && !b.IsAdmin(req.UserID) {
+		return "Sorry, only whoever posted a job or an admin can take it down.", nil
+	}
+
+	if err := j.takeDown(b, p); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Took down `%s`.", id), nil
+}
+
+// takeDown deletes p's card from the jobs channel and forgets it.
+func (j *Board) takeDown(b *mcdowell.Bot, p Posting) error {
+	if p.Channel != "" && p.Timestamp != "" {
+		if _, _, err := b.Client().DeleteMessage(p.Channel, p.Timestamp); err != nil {
+			log.Printf("unable to delete the card for job %s: %v\n", p.ID, err)
+		}
+	}
+
+	return errors.WithStack(b.Store().Delete(postingsBucket, p.ID))
+}
+
+// expire takes down postings that have expired.
+func (j *Board) expire(b *mcdowell.Bot, last time.Time) error {
+	postings, err := Postings(b.Store())
+	if err != nil {
+		return err
+	}
+
+	now := b.Now()
+
+	for _, p := range postings {
+		if p.Expires.After(now) {
+			continue
+		}
+
+		if err := j.takeDown(b, p); err != nil {
+			return err
+		}
+
+		if b.Debug {
+			log.Printf("job %s expired\n", p.ID)
+		}
+	}
+
+	return nil
+}
+
+// Postings returns every posting in store, newest first.
+func Postings(store mcdowell.Store) ([]Posting, error) {
+	ids, err := store.Keys(postingsBucket)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var postings []Posting
+
+	for _, id := range ids {
+		p, err := get(store, id)
+		if err == mcdowell.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		postings = append(postings, p)
+	}
+
+	sort.SliceStable(postings, func(i, j int) bool {
+		return postings[i].Posted.After(postings[j].Posted)
+	})
+
+	return postings, nil
+}
+
+func get(store mcdowell.Store, id string) (Posting, error) {
+	var p Posting
+
+	value, err := store.Get(postingsBucket, id)
+	if err != nil {
+		return p, err
+	}
+
+	return p, errors.Wrapf(json.Unmarshal(value, &p), "corrupt job %s", id)
+}
+
+func put(store mcdowell.Store, p Posting) error {
+	value, err := json.Marshal(p)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return store.Put(postingsBucket, p.ID, value)
+}
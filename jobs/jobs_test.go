@@ -0,0 +1,221 @@
+package jobs_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+	"github.com/willmadison/mcdowell/jobs"
+)
+
+const admin = slacktest.Admin
+
+func newJobsBot(t *testing.T, config string) (*mcdowell.Bot, *slacktest.Server, *slacktest.Clock) {
+	t.Helper()
+
+	return slacktest.NewBot(t, config, map[string]string{
+		"conversations.list": `{"ok": true, "channels": [{"id": "C00000JOBS", "name": "jobs"}, {"id": "C0000CAREERS", "name": "careers"}]}`,
+	}, mcdowell.WithPlugin(jobs.New()))
+}
+
+// submit submits the job posting form as user, filled in with fields.
+func submit(t *testing.T, b *mcdowell.Bot, user string, fields map[string]string) error {
+	t.Helper()
+
+	values := map[string]interface{}{}
+	for name, value := range fields {
+		input := map[string]interface{}{"type": "plain_text_input", "value": value}
+		if name == "workplace" {
+			input = map[string]interface{}{"type": "static_select", "selected_option": map[string]string{"value": value}}
+		}
+
+		values[name] = map[string]interface{}{name: input}
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"type": "view_submission",
+		"user": map[string]string{"id": user},
+		"view": map[string]interface{}{"callback_id": "jobs:post", "state": map[string]interface{}{"values": values}},
+	})
+	assert.Nil(t, err)
+
+	var submission mcdowell.ViewSubmission
+	assert.Nil(t, json.Unmarshal(payload, &submission))
+
+	return b.HandleEvent(&submission)
+}
+
+// openedForm returns the form opened by the views.open call.
+func openedForm(t *testing.T, call slacktest.Call) (string, string, []string) {
+	t.Helper()
+
+	var opened struct {
+		TriggerID string `json:"trigger_id"`
+		View      struct {
+			Type       string `json:"type"`
+			CallbackID string `json:"callback_id"`
+			Blocks     []struct {
+				Type    string `json:"type"`
+				BlockID string `json:"block_id"`
+			} `json:"blocks"`
+		} `json:"view"`
+	}
+	assert.Nil(t, json.Unmarshal(call.Body, &opened))
+	assert.Equal(t, "modal", opened.View.Type)
+
+	var fields []string
+	for _, block := range opened.View.Blocks {
+		assert.Equal(t, "input", block.Type)
+		fields = append(fields, block.BlockID)
+	}
+
+	return opened.TriggerID, opened.View.CallbackID, fields
+}
+
+func TestPostingAJobOpensAForm(t *testing.T) {
+	b, fake, _ := newJobsBot(t, `{}`)
+
+	assert.Equal(t, "Tell me about the job and I’ll share it in the jobs channel.", slacktest.Run(t, b, "U0AKEEM", "job", "post"))
+
+	calls := fake.Calls("views.open")
+	assert.Len(t, calls, 1)
+
+	trigger, callback, fields := openedForm(t, calls[0])
+	assert.Equal(t, "12345.98765", trigger)
+	assert.Equal(t, "jobs:post", callback)
+	assert.Equal(t, []string{"title", "company", "location", "workplace", "salary", "link", "tags"}, fields)
+}
+
+func TestPostingAJobFromAMessageOffersAButton(t *testing.T) {
+	b, fake, _ := newJobsBot(t, `{}`)
+
+	reply, err := b.RunCommand(&mcdowell.CommandRequest{Command: "job", Args: []string{"post"}, UserID: "U0AKEEM", ChannelID: "D0AKEEM"})
+	assert.Nil(t, err)
+	assert.Empty(t, reply)
+	assert.Empty(t, fake.Calls("views.open"))
+
+	posts := fake.Calls("chat.postMessage")
+	assert.Len(t, posts, 1)
+	assert.Equal(t, "D0AKEEM", posts[0].Form.Get("channel"))
+	assert.Contains(t, posts[0].Form.Get("blocks"), `"action_id":"jobs:open_form"`)
+
+	var click slack.InteractionCallback
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"type": "block_actions",
+		"trigger_id": "67890.12345",
+		"user": {"id": "U0AKEEM"},
+		"actions": [{"type": "button", "block_id": "b1", "action_id": "jobs:open_form"}]
+	}`), &click))
+	assert.Nil(t, b.HandleEvent(&click))
+
+	calls := fake.Calls("views.open")
+	assert.Len(t, calls, 1)
+
+	trigger, callback, _ := openedForm(t, calls[0])
+	assert.Equal(t, "67890.12345", trigger)
+	assert.Equal(t, "jobs:post", callback)
+}
+
+func TestPostedJobsAreSharedAndSearchable(t *testing.T) {
+	b, fake, c := newJobsBot(t, `{}`)
+
+	err := submit(t, b, "U0AKEEM", map[string]string{
+		"title":     "Senior Go Engineer",
+		"company":   "Zamunda Tech",
+		"location":  "Atlanta, GA",
+		"workplace": "remote",
+		"salary":    "$150k - $180k",
+		"link":      "https://zamunda.tech/jobs/1",
+		"tags":      "Golang, Kubernetes",
+	})
+	assert.Nil(t, err)
+
+	posts := fake.Calls("chat.postMessage")
+	assert.Len(t, posts, 2)
+	assert.Equal(t, "C00000JOBS", posts[0].Form.Get("channel"))
+	assert.Equal(t, "Senior Go Engineer at Zamunda Tech: https://zamunda.tech/jobs/1", posts[0].Form.Get("text"))
+
+	var card []struct {
+		Text struct {
+			Text string `json:"text"`
+		} `json:"text"`
+		Elements []struct {
+			Text string `json:"text"`
+		} `json:"elements"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(posts[0].Form.Get("blocks")), &card))
+	assert.Len(t, card, 2)
+
+	assert.Equal(t, "*<https://zamunda.tech/jobs/1|Senior Go Engineer>*\nZamunda Tech · Remote · Atlanta, GA\n:moneybag: $150k - $180k\n:hammer_and_wrench: golang, kubernetes", card[0].Text.Text)
+	assert.Equal(t, "Posted by <@U0AKEEM> · Open until July 1 · `zamunda-tech-senior-go-engineer`", card[1].Elements[0].Text)
+
+	assert.Equal(t, "U0AKEEM", posts[1].Form.Get("channel"))
+	assert.Equal(t, "Thanks! Your posting is up in <#C00000JOBS>. It comes down in 30 days, or sooner with `job remove zamunda-tech-senior-go-engineer`.", posts[1].Form.Get("text"))
+
+	c.Add(time.Hour)
+
+	assert.Nil(t, submit(t, b, "U0SEMMI", map[string]string{
+		"title":     "Frontend Developer",
+		"company":   "McDowell's",
+		"location":  "Queens, NY",
+		"workplace": "onsite",
+		"link":      "http://mcdowells.com/careers",
+		"tags":      "react",
+	}))
+
+	assert.Equal(t, `*Open jobs* (2)
+• *<http://mcdowells.com/careers|Frontend Developer>* at McDowell's (On-site, Queens, NY)
+• *<https://zamunda.tech/jobs/1|Senior Go Engineer>* at Zamunda Tech (Remote, Atlanta, GA) - $150k - $180k`, slacktest.Run(t, b, "U0AKEEM", "jobs"))
+
+	assert.Equal(t, `*Open jobs* (1)
+• *<https://zamunda.tech/jobs/1|Senior Go Engineer>* at Zamunda Tech (Remote, Atlanta, GA) - $150k - $180k`, slacktest.Run(t, b, "U0AKEEM", "jobs", "remote", "golang"))
+
+	assert.Contains(t, slacktest.Run(t, b, "U0AKEEM", "jobs", "React"), "Frontend Developer")
+	assert.Equal(t, `No open jobs match "remote react".`, slacktest.Run(t, b, "U0AKEEM", "jobs", "remote", "react"))
+
+	// Postings come down after 30 days, cards and all.
+	fake.Reset()
+	c.Add(30 * 24 * time.Hour)
+	assert.Nil(t, b.RunDueJobs())
+
+	deleted := fake.Calls("chat.delete")
+	assert.Len(t, deleted, 2)
+	assert.Equal(t, "C00000JOBS", deleted[0].Form.Get("channel"))
+	assert.Equal(t, "123.456", deleted[0].Form.Get("ts"))
+
+	assert.Equal(t, "No open jobs right now. Know of one? Share it with `/mcdowell job post`.", slacktest.Run(t, b, "U0AKEEM", "jobs"))
+}
+
+func TestInvalidPostingsAreRejected(t *testing.T) {
+	b, fake, _ := newJobsBot(t, `{}`)
+
+	err := submit(t, b, "U0AKEEM", map[string]string{"title": "Prince", "workplace": "castle", "link": "zamunda"})
+
+	assert.Equal(t, mcdowell.SubmissionErrors{
+		"company":   "Who’s hiring?",
+		"workplace": "Pick remote, hybrid or on-site.",
+		"link":      "Enter a link to the posting, starting with https://",
+	}, err)
+
+	assert.Empty(t, fake.Calls("chat.postMessage"))
+}
+
+func TestRemovingJobs(t *testing.T) {
+	b, _, _ := newJobsBot(t, `{"plugins": {"jobs": {"channel": "#careers", "expire_after": "72h"}}}`)
+
+	posting := map[string]string{"title": "Fry Cook", "company": "McDowell's", "workplace": "onsite", "link": "https://mcdowells.com"}
+
+	assert.Nil(t, submit(t, b, "U0AKEEM", posting))
+	assert.Nil(t, submit(t, b, "U0AKEEM", posting))
+
+	assert.Contains(t, slacktest.Run(t, b, "U0AKEEM", "jobs"), "(2)")
+
+	assert.Equal(t, "Sorry, only whoever posted a job or an admin can take it down.", slacktest.Run(t, b, "U0SEMMI", "job", "remove", "mcdowell-s-fry-cook"))
+	assert.Equal(t, "Took down `mcdowell-s-fry-cook`.", slacktest.Run(t, b, "U0AKEEM", "job", "remove", "mcdowell-s-fry-cook"))
+	assert.Equal(t, "Took down `mcdowell-s-fry-cook-2`.", slacktest.Run(t, b, admin, "job", "remove", "mcdowell-s-fry-cook-2"))
+	assert.Equal(t, "Sorry, there's no job `mcdowell-s-fry-cook`.", slacktest.Run(t, b, admin, "job", "remove", "mcdowell-s-fry-cook"))
+}
@@ -0,0 +1,233 @@
+package mcdowell
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// karmaBucket is where karma is stored, keyed by user ID.
+const karmaBucket = "karma"
+
+// leaderboardSize is how many members each leaderboard shows.
+const leaderboardSize = 10
+
+// karmaPattern matches mentions directly followed by ++ or --, e.g.
+// "<@U024BE7LH>++", then whitespace, punctuation other than a dash, or the end
+// of the message, so "<@U024BE7LH> -- see above" isn't a downvote.
+var karmaPattern = regexp.MustCompile(`<@([UW][A-Z0-9]+)(?:\|[^>]*)?>(\+\+|--)(?:\s|[^\P{P}-]|$)`)
+
+type (
+	// karma is a member's all time score along with the recent changes to it
+	// that the weekly and monthly leaderboards are tallied from.
+	karma struct {
+		Total   int           `json:"total"`
+		Changes []karmaChange `json:"changes,omitempty"`
+	}
+
+	karmaChange struct {
+		At    time.Time `json:"at"`
+		Delta int       `json:"delta"`
+	}
+
+	karmaScore struct {
+		UserID string
+		Score  int
+	}
+)
+
+// since tallies the changes made to k at or after start.
+func (k karma) since(start time.Time) int {
+	var score int
+
+	for _, change := range k.Changes {
+		if !change.At.Before(start) {
+			score += change.Delta
+		}
+	}
+
+	return score
+}
+
+// startOfWeek returns midnight on the Monday of t's week.
+func startOfWeek(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	year, month, day := t.AddDate(0, 0, -daysSinceMonday).Date()
+
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// startOfMonth returns midnight on the first of t's month.
+func startOfMonth(t time.Time) time.Time {
+	year, month, _ := t.Date()
+
+	return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+}
+
+// onKarma gives or takes karma from everyone mentioned with ++ or -- in
+// event, replying with their new totals. Members can't give themselves karma.
+func (b *Bot) onKarma(event *slack.MessageEvent) error {
+	matches := karmaPattern.FindAllStringSubmatch(event.Text, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var replies []string
+	seen := map[string]bool{}
+
+	for _, match := range matches {
+		userID, operator := match[1], match[2]
+
+		if seen[userID] {
+			continue
+		}
+
+		seen[userID] = true
+
+		if userID == event.User {
+			replies = append(replies, fmt.Sprintf("Nice try, <@%s>. You can’t give yourself karma.", userID))
+			continue
+		}
+
+		delta := 1
+		if operator == "--" {
+			delta = -1
+		}
+
+		total, err := b.addKarma(userID, delta)
+		if err != nil {
+			return err
+		}
+
+		replies = append(replies, fmt.Sprintf("<@%s>’s karma is now %d.", userID, total))
+	}
+
+	_, _, err := b.client.PostMessage(event.Channel,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(strings.Join(replies, "\n"), false),
+	)
+
+	return errors.WithStack(err)
+}
+
+// addKarma adds delta to userID's karma and returns their new total.
+func (b *Bot) addKarma(userID string, delta int) (int, error) {
+	b.karmaMu.Lock()
+	defer b.karmaMu.Unlock()
+
+	var k karma
+
+	err := getJSON(b.store, karmaBucket, userID, &k)
+	if err != nil && err != ErrNotFound {
+		return 0, err
+	}
+
+	now := b.now()
+
+	// Changes are only kept as long as the current week or month needs them.
+	cutoff := startOfMonth(now)
+	if week := startOfWeek(now); week.Before(cutoff) {
+		cutoff = week
+	}
+
+	var changes []karmaChange
+	for _, change := range k.Changes {
+		if !change.At.Before(cutoff) {
+			changes = append(changes, change)
+		}
+	}
+
+	k.Total += delta
+	k.Changes = append(changes, karmaChange{At: now, Delta: delta})
+
+	if err := putJSON(b.store, karmaBucket, userID, k); err != nil {
+		return 0, err
+	}
+
+	return k.Total, nil
+}
+
+// leaderboards returns the top scores for this week, this month and all time.
+func (b *Bot) leaderboards() (week, month, allTime []karmaScore, err error) {
+	userIDs, err := b.store.Keys(karmaBucket)
+	if err != nil {
+		return nil, nil, nil, errors.WithStack(err)
+	}
+
+	now := b.now()
+	weekStart, monthStart := startOfWeek(now), startOfMonth(now)
+
+	for _, userID := range userIDs {
+		var k karma
+
+		err := getJSON(b.store, karmaBucket, userID, &k)
+		if err == ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		week = append(week, karmaScore{UserID: userID, Score: k.since(weekStart)})
+		month = append(month, karmaScore{UserID: userID, Score: k.since(monthStart)})
+		allTime = append(allTime, karmaScore{UserID: userID, Score: k.Total})
+	}
+
+	return top(week), top(month), top(allTime), nil
+}
+
+// top returns the highest positive scores, best first.
+func top(scores []karmaScore) []karmaScore {
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+
+	var leaders []karmaScore
+	for _, score := range scores {
+		if score.Score <= 0 || len(leaders) == leaderboardSize {
+			break
+		}
+
+		leaders = append(leaders, score)
+	}
+
+	return leaders
+}
+
+func leaderboard(b *Bot, req *CommandRequest) (string, error) {
+	week, month, allTime, err := b.leaderboards()
+	if err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+
+	for _, board := range []struct {
+		title  string
+		scores []karmaScore
+	}{
+		{"This week", week},
+		{"This month", month},
+		{"All time", allTime},
+	} {
+		fmt.Fprintf(&text, "*%s*\n", board.title)
+
+		if len(board.scores) == 0 {
+			text.WriteString("Nobody yet. Give someone a `++`!\n")
+		}
+
+		for i, score := range board.scores {
+			fmt.Fprintf(&text, "%d. %s (%d)\n", i+1, b.directory.Addressed(score.UserID), score.Score)
+		}
+
+		text.WriteString("\n")
+	}
+
+	return strings.TrimSpace(text.String()), nil
+}
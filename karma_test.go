@@ -0,0 +1,99 @@
+package mcdowell_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+func giveKarma(t *testing.T, m *mcdowell.Bot, from, text string) {
+	t.Helper()
+
+	err := m.OnNewMessage(&slack.MessageEvent{Msg: slack.Msg{Channel: "C0000GENERAL", User: from, Text: text}})
+	assert.Nil(t, err)
+}
+
+func TestKarma(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+
+	giveKarma(t, m, "U0AKEEM", "<@U0SEMMI>++ thanks for the help!")
+	assert.Equal(t, "C0000GENERAL", fake.Last().Form.Get("channel"))
+	assert.Equal(t, "<@U0SEMMI>’s karma is now 1.", fake.Last().Form.Get("text"))
+
+	giveKarma(t, m, "U0AKEEM", "<@U0SEMMI|semmi>++ <@U0DARRYL>--, <@U0SEMMI>++")
+	assert.Equal(t, "<@U0SEMMI>’s karma is now 2.\n<@U0DARRYL>’s karma is now -1.", fake.Last().Form.Get("text"))
+
+	fake.Reset()
+
+	giveKarma(t, m, "U0AKEEM", "I have no karma to give")
+	assert.Empty(t, fake.All())
+}
+
+func TestDashesInSentencesArentKarma(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+
+	for _, text := range []string{
+		"hey <@U0SEMMI> -- did you see this?",
+		"<@U0SEMMI> ++",
+		"<@U0SEMMI>--- see above",
+		"<@U0SEMMI>++more",
+	} {
+		giveKarma(t, m, "U0AKEEM", text)
+	}
+
+	assert.Empty(t, fake.All())
+
+	giveKarma(t, m, "U0AKEEM", "(thanks <@U0SEMMI>++)")
+	assert.Equal(t, "<@U0SEMMI>’s karma is now 1.", fake.Last().Form.Get("text"))
+}
+
+func TestSelfKarmaIsBlocked(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+
+	giveKarma(t, m, "U0SEMMI", "<@U0SEMMI>++")
+	assert.Equal(t, "Nice try, <@U0SEMMI>. You can’t give yourself karma.", fake.Last().Form.Get("text"))
+
+	giveKarma(t, m, "U0AKEEM", "<@U0SEMMI>++")
+	assert.Equal(t, "<@U0SEMMI>’s karma is now 1.", fake.Last().Form.Get("text"))
+}
+
+func TestLeaderboard(t *testing.T) {
+	m, _, clock := slacktest.NewBot(t, "", nil)
+
+	leaderboard := func() string {
+		reply, err := m.RunCommand(&mcdowell.CommandRequest{Command: "leaderboard", UserID: "U0CLEO"})
+		assert.Nil(t, err)
+
+		return reply
+	}
+
+	assert.Equal(t, "*This week*\nNobody yet. Give someone a `++`!\n\n*This month*\nNobody yet. Give someone a `++`!\n\n*All time*\nNobody yet. Give someone a `++`!", leaderboard())
+
+	clock.Set(time.Date(1988, time.May, 20, 12, 0, 0, 0, time.UTC))
+	for i := 0; i < 3; i++ {
+		giveKarma(t, m, "U0SEMMI", "<@U0AKEEM>++")
+	}
+
+	clock.Set(time.Date(1988, time.June, 10, 12, 0, 0, 0, time.UTC))
+	giveKarma(t, m, "U0AKEEM", "<@U0SEMMI>++")
+	giveKarma(t, m, "U0LISA", "<@U0SEMMI>++")
+
+	clock.Set(time.Date(1988, time.June, 29, 12, 0, 0, 0, time.UTC))
+	giveKarma(t, m, "U0AKEEM", "<@U0LISA>++ <@U0DARRYL>--")
+
+	assert.Equal(t, `*This week*
+1. <@U0LISA> (1)
+
+*This month*
+1. <@U0SEMMI> (2)
+2. <@U0LISA> (1)
+
+*All time*
+1. <@U0AKEEM> (3)
+2. <@U0SEMMI> (2)
+3. <@U0LISA> (1)`, leaderboard())
+}
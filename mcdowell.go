@@ -3,10 +3,15 @@ package mcdowell
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"log"
+	"os"
 
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/nlopes/slack"
 	"github.com/pkg/errors"
@@ -15,137 +20,134 @@ import (
 type (
 	// Bot represents a single bot instance.
 	Bot struct {
-		id           string
-		name         string
-		client       SlackClient
-		ctx          context.Context
-		contributors map[string]string
-		Debug        bool
-		Testing      bool
-		Version      string
+		identity Identity
+		client   SlackClient
+		ctx      context.Context
+
+		rosterMu   sync.RWMutex
+		roster     roster
+		opsChannel string
+
+		triggerCatalog        string
+		triggerReloadInterval time.Duration
+		reloadMu              sync.Mutex
+		triggersMu            sync.RWMutex
+		triggers              []trigger
+		matchPolicy           MatchPolicy
+		cooldowns             *cooldowns
+
+		commandsMu    sync.RWMutex
+		commands      map[string]Command
+		signingSecret string
+
+		config           *Config
+		interestChannels map[string][]string
+		welcomeTemplate  *template.Template
+		featuredChannels []FeaturedChannel
+
+		introductionTemplates *introductionTemplates
+		introductionsChannel  FeaturedChannel
+
+		directory *Directory
+
+		store   Store
+		karmaMu sync.Mutex
+
+		plugins  []Plugin
+		handlers map[string][]pluginHandler
+
+		location          *time.Location
+		schedulerMu       sync.Mutex
+		jobsMu            sync.Mutex
+		jobs              map[string]scheduledJob
+		digestSections    []DigestSection
+		winsChannel       string
+		newsletterChannel string
+
+		apiToken string
+		apiURL   string
+
+		now func() time.Time
+
+		Debug   bool
+		Testing bool
+		Version string
 	}
 
 	// SlackClient represents the interface of methods we rely on from the Slack client.
 	SlackClient interface {
 		PostMessage(channel string, options ...slack.MsgOption) (string, string, error)
-		GetUsers() ([]slack.User, error)
+		PostEphemeral(channelID, userID string, options ...slack.MsgOption) (string, error)
+		GetConversations(params *slack.GetConversationsParameters) ([]slack.Channel, string, error)
+		InviteUsersToConversation(channelID string, users ...string) (*slack.Channel, error)
+		GetUsersPaginated(options ...slack.GetUsersOption) slack.UserPagination
+		GetUserGroups(options ...slack.GetUserGroupsOption) ([]slack.UserGroup, error)
+		GetUserGroupMembers(userGroup string) ([]string, error)
+		GetUserInfo(user string) (*slack.User, error)
+		AuthTest() (*slack.AuthTestResponse, error)
+		GetFile(downloadURL string, writer io.Writer) error
+		DeleteMessage(channel, messageTimestamp string) (string, string, error)
+		OpenConversation(params *slack.OpenConversationParameters) (*slack.Channel, bool, bool, error)
 	}
 )
 
 func (b *Bot) initialize() error {
-	if b.Debug {
-		log.Println("determining bot/contributor user IDs:")
-	}
-
-	users, err := b.client.GetUsers()
+	err := b.resolveIdentity()
 	if err != nil {
-		return errors.WithStack(err)
-	}
-
-	b.contributors = map[string]string{}
-
-	for _, user := range users {
-		switch user.Name {
-		case "willmadison", "xango":
-			b.contributors[user.Name] = user.ID
-		case b.name:
-			if user.IsBot {
-				b.id = user.ID
-			}
-		default:
-			continue
-		}
+		return err
 	}
 
 	if b.Debug {
-		log.Println("contributors:", b.contributors)
+		log.Println("determining roster user IDs:")
 	}
 
-	if b.id == "" && !b.Testing {
-		return errors.New("could not find bot in the list of names, ensure the bot is called \"" + b.name + "\" ")
+	err = b.loadDirectory()
+	if err != nil {
+		return err
 	}
 
-	log.Printf("Initialized %s with ID: %s\n", b.name, b.id)
-
-	message := fmt.Sprintf(`sucessfully deployed %s v%s...`, b.name, b.Version)
+	err = b.resolveRoster()
+	if err != nil {
+		return err
+	}
 
-	_, _, err = b.client.PostMessage(b.contributors["willmadison"],
-		slack.MsgOptionAsUser(true),
-		slack.MsgOptionText(message, false),
-	)
+	err = b.resolveConfigChannels()
 	if err != nil {
-		log.Printf(`failed to notify @willmadison regarding %s deployment`, b.name)
+		return err
 	}
 
+	log.Printf("Initialized %s with ID: %s (bot ID: %s) in %s (%s)\n", b.identity.Name, b.identity.UserID, b.identity.BotID, b.identity.Team, b.identity.TeamID)
+
 	return nil
 }
 
 // OnTeamJoined handles the appropriate behavior for when new team members join our slack.
 func (b *Bot) OnTeamJoined(event *slack.TeamJoinEvent) error {
-	message := `Yo ` + event.User.Name + `!
-
-I’d like to welcome you to the Atlanta Black Tech Family. Our mission is to improve the quality, quantity, and connections for people of African descent within the overall Metro Atlanta tech ecosystem.
+	b.directory.Update(event.User)
 
-Please click on “Channels” to browse all of our sub-communities, and join the ones that are most relevant to you. Enjoy your time, and help us build the communities by inviting others in your network.`
+	message, err := render(b.welcomeTemplate, b.welcomeData(event.User))
+	if err != nil {
+		return err
+	}
 
-	_, _, err := b.client.PostMessage(event.User.ID,
+	options := []slack.MsgOption{
 		slack.MsgOptionAsUser(true),
 		slack.MsgOptionText(message, false),
 		slack.MsgOptionPostMessageParameters(slack.PostMessageParameters{
 			LinkNames: 1,
 		}),
-	)
-
-	return err
-}
-
-var botEventTextToResponses = map[string]func(*Bot, *slack.MessageEvent) error{
-	"show me the money":     heHasHisOwnMoney("The boy has got his own money!"),
-	"let me hold something": heHasHisOwnMoney("I got you!"),
-	"soul glo":              soulGlo,
-	"queen":                 queenToBe,
-}
+	}
 
-func heHasHisOwnMoney(message string) func(*Bot, *slack.MessageEvent) error {
-	return func(b *Bot, event *slack.MessageEvent) error {
-		_, _, err := b.client.PostMessage(event.Channel,
-			slack.MsgOptionAsUser(true),
-			slack.MsgOptionEnableLinkUnfurl(),
-			slack.MsgOptionAttachments(
-				slack.Attachment{
-					Text:     message,
-					ImageURL: "https://novembrepleut.files.wordpress.com/2011/06/zamundamoney_100.png",
-				},
-			),
-		)
-		return err
+	if b.onboarding() != nil {
+		options = append(options, slack.MsgOptionBlocks(b.onboardingBlocks(message)...))
 	}
-}
 
-func soulGlo(b *Bot, event *slack.MessageEvent) error {
-	_, _, err := b.client.PostMessage(event.Channel,
-		slack.MsgOptionAsUser(true),
-		slack.MsgOptionEnableLinkUnfurl(),
-		slack.MsgOptionAttachments(
-			slack.Attachment{
-				ImageURL: "https://media.giphy.com/media/3Gz3vy81HkDa8/giphy.gif",
-			},
-		),
-	)
-	return err
-}
+	_, _, err = b.client.PostMessage(event.User.ID, options...)
+	if err != nil {
+		return errors.WithStack(err)
+	}
 
-func queenToBe(b *Bot, event *slack.MessageEvent) error {
-	_, _, err := b.client.PostMessage(event.Channel,
-		slack.MsgOptionAsUser(true),
-		slack.MsgOptionEnableLinkUnfurl(),
-		slack.MsgOptionAttachments(
-			slack.Attachment{
-				ImageURL: "https://img.memesuper.com/bc7ab2796bdb983d5434fc842efcee0b_coming-to-america-aha-meme-coming-to-america_500-263.gif",
-			},
-		),
-	)
-	return err
+	return b.introduce(event.User)
 }
 
 // OnNewMessage handles the appropriate behavior for when new interesting
@@ -155,40 +157,210 @@ func (b *Bot) OnNewMessage(event *slack.MessageEvent) error {
 		return nil
 	}
 
-	eventText := strings.Trim(strings.ToLower(event.Text), " \n\r")
+	if event.Channel != "" && event.Channel == b.introductionsChannel.ID {
+		b.introduced(event.User)
+	}
+
+	if b.winsChannel != "" && event.Channel == b.winsChannel {
+		b.recordWin(event)
+	}
+
+	eventText := strings.Trim(event.Text, " \n\r")
 
 	if b.Debug || b.Testing {
 		log.Printf("event: %+v\n", *event)
 		log.Println("got message:", eventText)
 	}
 
+	if command, ok := b.addressedCommand(event); ok {
+		return b.onCommandMessage(event, command)
+	}
+
+	if karmaErr := b.onKarma(event); karmaErr != nil {
+		log.Println("failed to record karma:", karmaErr)
+	}
+
 	var err error
-	for fragment, response := range botEventTextToResponses {
-		if strings.Contains(eventText, fragment) {
-			err = response(b, event)
+	for _, m := range matchTriggers(b.activeTriggers(), event.Channel, eventText, b.matchPolicy) {
+		if remaining, ok := b.cooldowns.allow(m.trigger, event.Channel, event.User, b.now()); !ok {
+			if b.Debug || b.Testing {
+				log.Printf("trigger %q is cooling down for another %s\n", m.Phrase, remaining)
+			}
+
+			if m.Cooldown.Notice == NoticeEphemeral {
+				_, noticeErr := b.client.PostEphemeral(event.Channel, event.User,
+					slack.MsgOptionText(m.Cooldown.notice(remaining), false),
+				)
+				if noticeErr != nil {
+					log.Printf("failed to send cooldown notice for %q: %v\n", m.Phrase, noticeErr)
+				}
+			}
+
+			continue
+		}
+
+		if respondErr := m.respond(b, event, eventText, m.submatch); respondErr != nil {
+			log.Printf("trigger %q failed to respond: %v\n", m.Phrase, respondErr)
+
+			if err == nil {
+				err = respondErr
+			}
 		}
 	}
 
 	return err
 }
 
+// HandleEvent hands an event received from Slack to the matching handler.
+// Events the bot has no interest in are ignored.
+func (b *Bot) HandleEvent(event interface{}) error {
+	var err error
+
+	switch e := event.(type) {
+	case *slack.MessageEvent:
+		err = b.OnNewMessage(e)
+	case *slack.TeamJoinEvent:
+		err = b.OnTeamJoined(e)
+	case *slack.UserChangeEvent:
+		err = b.OnUserChanged(e)
+	case *slack.SlashCommand:
+		err = b.onSlashCommand(e)
+	case *slack.InteractionCallback:
+		err = b.onInteraction(e)
+	}
+
+	if pluginErr := b.runPlugins(event); err == nil {
+		err = pluginErr
+	}
+
+	return err
+}
+
+// dispatch handles event in the background, or right away in test mode.
+func (b *Bot) dispatch(event interface{}) {
+	b.dispatchTo(b.HandleEvent, event)
+}
+
+// dispatchTo hands event to handler in the background, or right away in test mode.
+func (b *Bot) dispatchTo(handler func(event interface{}) error, event interface{}) {
+	handle := func() {
+		if err := handler(event); err != nil {
+			log.Printf("failed to handle %T: %v\n", event, err)
+		}
+	}
+
+	if b.Testing {
+		handle()
+		return
+	}
+
+	go handle()
+}
+
 // NewBot returns a new McDowell Bot instance ready to handle any events from Slack.
 func NewBot(ctx context.Context, client SlackClient, options ...func(*Bot)) (*Bot, error) {
 	b := &Bot{
 		ctx:    ctx,
 		client: client,
-		name:   "mcdowell",
+
+		triggerReloadInterval: DefaultTriggerReloadInterval,
+		matchPolicy:           AllMatches,
+		cooldowns:             newCooldowns(),
+		directory:             newDirectory(),
+		apiURL:                slack.APIURL,
+		now:                   time.Now,
 	}
 
 	for _, option := range options {
 		option(b)
 	}
 
-	err := b.initialize()
+	if b.store == nil {
+		b.store = NewMemoryStore()
+	}
+
+	switch b.matchPolicy {
+	case FirstMatch, AllMatches, RandomMatch:
+	default:
+		return nil, errors.Errorf("unknown match policy %q", b.matchPolicy)
+	}
+
+	timeZone := DefaultTimeZone
+	if b.config != nil && b.config.TimeZone != "" {
+		timeZone = b.config.TimeZone
+	}
+
+	location, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unknown time zone %q", timeZone)
+	}
+
+	b.location = location
+
+	var welcome *WelcomeConfig
+	if b.config != nil {
+		welcome = b.config.Welcome
+	}
+
+	welcomeTemplate, err := welcome.template()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid welcome template")
+	}
+
+	b.welcomeTemplate = welcomeTemplate
+
+	if introductions := b.introductions(); introductions != nil {
+		b.introductionTemplates, err = introductions.templates()
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid introductions")
+		}
+	}
+
+	err = b.registerBuiltinCommands()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	catalogInfo, _ := os.Stat(b.triggerCatalog)
+
+	err = b.loadTriggers()
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
 
+	err = b.initialize()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	err = b.registerBuiltinJobs()
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.initPlugins()
+	if err != nil {
+		return nil, err
+	}
+
+	b.notifyRoster(fmt.Sprintf(`sucessfully deployed %s v%s...`, b.identity.Name, b.Version))
+
+	if b.triggerCatalog != "" && b.triggerReloadInterval > 0 {
+		go b.watchTriggerCatalog(catalogInfo)
+	}
+
+	if b.introductionTemplates != nil {
+		go b.watchIntroductions()
+	}
+
+	// Catch up on anything that came due while the bot was down before
+	// keeping to the schedule.
+	if err := b.RunDueJobs(); err != nil {
+		log.Println("failed to run overdue jobs:", err)
+	}
+
+	go b.runScheduler()
+
 	return b, nil
 }
 
@@ -206,6 +378,13 @@ func WithTesting() func(*Bot) {
 	}
 }
 
+// WithClock sets the function the bot uses to tell the time, for tests.
+func WithClock(now func() time.Time) func(*Bot) {
+	return func(b *Bot) {
+		b.now = now
+	}
+}
+
 // Versioned enables Version to be set on the bot.
 func Versioned(version string) func(*Bot) {
 	return func(b *Bot) {
@@ -1,73 +1,17 @@
 package mcdowell_test
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"net/http/httptest"
-	"net/url"
 	"strconv"
-	"strings"
 	"testing"
 
 	"github.com/nlopes/slack"
 	"github.com/stretchr/testify/assert"
-	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
 )
 
-type captured struct {
-	Path        string
-	ContentType string
-	Body        []byte
-	JSON        map[string]any
-	Form        url.Values
-}
-
-// startFakeSlack returns a test server that records requests and responds OK
-func startFakeSlack(t *testing.T) (*httptest.Server, *captured) {
-	t.Helper()
-	var cap captured
-
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cap.Path = r.URL.Path
-		cap.ContentType = r.Header.Get("Content-Type")
-		b, _ := io.ReadAll(r.Body)
-		cap.Body = b
-
-		// slack-go may send JSON or urlencoded; handle both
-		if strings.Contains(cap.ContentType, "application/json") {
-			_ = json.Unmarshal(b, &cap.JSON)
-		} else {
-			// Either form or "payload=JSON"
-			if v, err := url.ParseQuery(string(b)); err == nil {
-				cap.Form = v
-				if p := v.Get("payload"); p != "" {
-					_ = json.Unmarshal([]byte(p), &cap.JSON)
-				}
-			}
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-
-		// minimal OK reply for chat.postMessage
-		w.Write([]byte(`{"ok":true,"channel":"C123","ts":"123.456","message":{}}`))
-	}))
-
-	return srv, &cap
-}
-
 func TestBotHandlesTeamJoinEvents(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	srv, captured := startFakeSlack(t)
-	t.Cleanup(srv.Close)
-
-	client := slack.New("dummyToken", slack.OptionAPIURL(srv.URL+"/"))
-
-	m, err := mcdowell.NewBot(ctx, client, mcdowell.WithTesting())
-	assert.Nil(t, err)
+	m, fake, _ := slacktest.NewBot(t, "", nil)
 
 	testUser := slack.User{
 		ID:   "dummyID",
@@ -78,10 +22,10 @@ func TestBotHandlesTeamJoinEvents(t *testing.T) {
 		User: testUser,
 	}
 
-	err = m.OnTeamJoined(e)
+	err := m.OnTeamJoined(e)
 	assert.Nil(t, err)
 
-	assert.Equal(t, testUser.ID, captured.Form.Get("channel"))
+	assert.Equal(t, testUser.ID, fake.Last().Form.Get("channel"))
 
 	expected := `Yo Test User!
 
@@ -89,27 +33,19 @@ I’d like to welcome you to the Atlanta Black Tech Family. Our mission is to im
 
 Please click on “Channels” to browse all of our sub-communities, and join the ones that are most relevant to you. Enjoy your time, and help us build the communities by inviting others in your network.`
 
-	assert.Equal(t, expected, captured.Form.Get("text"))
+	assert.Equal(t, expected, fake.Last().Form.Get("text"))
 
-	actual_as_user, err := strconv.ParseBool(captured.Form.Get("as_user"))
+	actual_as_user, err := strconv.ParseBool(fake.Last().Form.Get("as_user"))
 	assert.Nil(t, err)
 	assert.True(t, actual_as_user)
 
-	actual_link_names, err := strconv.Atoi(captured.Form.Get("link_names"))
+	actual_link_names, err := strconv.Atoi(fake.Last().Form.Get("link_names"))
 	assert.Nil(t, err)
 	assert.Equal(t, 1, actual_link_names)
 }
 
 func TestShowMeTheMoney(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	srv, captured := startFakeSlack(t)
-	t.Cleanup(srv.Close)
-
-	client := slack.New("dummyToken", slack.OptionAPIURL(srv.URL+"/"))
-
-	m, err := mcdowell.NewBot(ctx, client, mcdowell.WithTesting())
-	assert.Nil(t, err)
+	m, fake, _ := slacktest.NewBot(t, "", nil)
 
 	message := slack.Msg{
 		Channel: "#general",
@@ -121,18 +57,16 @@ func TestShowMeTheMoney(t *testing.T) {
 		Msg: message,
 	}
 
-	err = m.OnNewMessage(e)
+	err := m.OnNewMessage(e)
 	assert.Nil(t, err)
 
-	fmt.Printf("captured: %+v\n", *captured)
-
-	assert.Equal(t, e.Channel, captured.Form.Get("channel"))
+	assert.Equal(t, e.Channel, fake.Last().Form.Get("channel"))
 
-	actual_unfurl_links, err := strconv.ParseBool(captured.Form.Get("unfurl_links"))
+	actual_unfurl_links, err := strconv.ParseBool(fake.Last().Form.Get("unfurl_links"))
 	assert.Nil(t, err)
 	assert.True(t, actual_unfurl_links)
 
-	raw_actual_attachments := captured.Form.Get("attachments")
+	raw_actual_attachments := fake.Last().Form.Get("attachments")
 
 	var actual_attachments []slack.Attachment
 
@@ -148,15 +82,7 @@ func TestShowMeTheMoney(t *testing.T) {
 }
 
 func TestLetMeHoldSomething(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	srv, captured := startFakeSlack(t)
-	t.Cleanup(srv.Close)
-
-	client := slack.New("dummyToken", slack.OptionAPIURL(srv.URL+"/"))
-
-	m, err := mcdowell.NewBot(ctx, client, mcdowell.WithTesting())
-	assert.Nil(t, err)
+	m, fake, _ := slacktest.NewBot(t, "", nil)
 
 	message := slack.Msg{
 		Channel: "#general",
@@ -168,20 +94,20 @@ func TestLetMeHoldSomething(t *testing.T) {
 		Msg: message,
 	}
 
-	err = m.OnNewMessage(e)
+	err := m.OnNewMessage(e)
 	assert.Nil(t, err)
 
-	assert.Equal(t, e.Channel, captured.Form.Get("channel"))
+	assert.Equal(t, e.Channel, fake.Last().Form.Get("channel"))
 
-	actual_as_user, err := strconv.ParseBool(captured.Form.Get("as_user"))
+	actual_as_user, err := strconv.ParseBool(fake.Last().Form.Get("as_user"))
 	assert.Nil(t, err)
 	assert.True(t, actual_as_user)
 
-	actual_unfurl_links, err := strconv.ParseBool(captured.Form.Get("unfurl_links"))
+	actual_unfurl_links, err := strconv.ParseBool(fake.Last().Form.Get("unfurl_links"))
 	assert.Nil(t, err)
 	assert.True(t, actual_unfurl_links)
 
-	raw_actual_attachments := captured.Form.Get("attachments")
+	raw_actual_attachments := fake.Last().Form.Get("attachments")
 
 	var actual_attachments []slack.Attachment
 
@@ -197,15 +123,7 @@ func TestLetMeHoldSomething(t *testing.T) {
 }
 
 func TestSoulGlo(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	srv, captured := startFakeSlack(t)
-	t.Cleanup(srv.Close)
-
-	client := slack.New("dummyToken", slack.OptionAPIURL(srv.URL+"/"))
-
-	m, err := mcdowell.NewBot(ctx, client, mcdowell.WithTesting())
-	assert.Nil(t, err)
+	m, fake, _ := slacktest.NewBot(t, "", nil)
 
 	message := slack.Msg{
 		Channel: "#general",
@@ -217,20 +135,20 @@ func TestSoulGlo(t *testing.T) {
 		Msg: message,
 	}
 
-	err = m.OnNewMessage(e)
+	err := m.OnNewMessage(e)
 	assert.Nil(t, err)
 
-	assert.Equal(t, e.Channel, captured.Form.Get("channel"))
+	assert.Equal(t, e.Channel, fake.Last().Form.Get("channel"))
 
-	actual_as_user, err := strconv.ParseBool(captured.Form.Get("as_user"))
+	actual_as_user, err := strconv.ParseBool(fake.Last().Form.Get("as_user"))
 	assert.Nil(t, err)
 	assert.True(t, actual_as_user)
 
-	actual_unfurl_links, err := strconv.ParseBool(captured.Form.Get("unfurl_links"))
+	actual_unfurl_links, err := strconv.ParseBool(fake.Last().Form.Get("unfurl_links"))
 	assert.Nil(t, err)
 	assert.True(t, actual_unfurl_links)
 
-	raw_actual_attachments := captured.Form.Get("attachments")
+	raw_actual_attachments := fake.Last().Form.Get("attachments")
 
 	var actual_attachments []slack.Attachment
 
@@ -246,15 +164,7 @@ func TestSoulGlo(t *testing.T) {
 }
 
 func TestQueenToBe(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	srv, captured := startFakeSlack(t)
-	t.Cleanup(srv.Close)
-
-	client := slack.New("dummyToken", slack.OptionAPIURL(srv.URL+"/"))
-
-	m, err := mcdowell.NewBot(ctx, client, mcdowell.WithTesting())
-	assert.Nil(t, err)
+	m, fake, _ := slacktest.NewBot(t, "", nil)
 
 	message := slack.Msg{
 		Channel: "#general",
@@ -266,20 +176,20 @@ func TestQueenToBe(t *testing.T) {
 		Msg: message,
 	}
 
-	err = m.OnNewMessage(e)
+	err := m.OnNewMessage(e)
 	assert.Nil(t, err)
 
-	assert.Equal(t, e.Channel, captured.Form.Get("channel"))
+	assert.Equal(t, e.Channel, fake.Last().Form.Get("channel"))
 
-	actual_as_user, err := strconv.ParseBool(captured.Form.Get("as_user"))
+	actual_as_user, err := strconv.ParseBool(fake.Last().Form.Get("as_user"))
 	assert.Nil(t, err)
 	assert.True(t, actual_as_user)
 
-	actual_unfurl_links, err := strconv.ParseBool(captured.Form.Get("unfurl_links"))
+	actual_unfurl_links, err := strconv.ParseBool(fake.Last().Form.Get("unfurl_links"))
 	assert.Nil(t, err)
 	assert.True(t, actual_unfurl_links)
 
-	raw_actual_attachments := captured.Form.Get("attachments")
+	raw_actual_attachments := fake.Last().Form.Get("attachments")
 
 	var actual_attachments []slack.Attachment
 
@@ -294,15 +204,7 @@ func TestQueenToBe(t *testing.T) {
 }
 
 func TestIgnoresBotMessages(t *testing.T) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	srv, captured := startFakeSlack(t)
-	t.Cleanup(srv.Close)
-
-	client := slack.New("dummyToken", slack.OptionAPIURL(srv.URL+"/"))
-
-	m, err := mcdowell.NewBot(ctx, client, mcdowell.WithTesting())
-	assert.Nil(t, err)
+	m, fake, _ := slacktest.NewBot(t, "", nil)
 
 	message := slack.Msg{
 		BotID:   "someId",
@@ -316,10 +218,10 @@ func TestIgnoresBotMessages(t *testing.T) {
 		Msg: message,
 	}
 
-	err = m.OnNewMessage(e)
+	err := m.OnNewMessage(e)
 	assert.Nil(t, err)
 
-	raw_actual_attachments := captured.Form.Get("attachments")
+	raw_actual_attachments := fake.Last().Form.Get("attachments")
 
 	var actual_attachments []slack.Attachment
 
@@ -0,0 +1,123 @@
+package mcdowell
+
+import (
+	"log"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// addressedCommand returns the command in event if it's meant for the bot:
+// anything sent in a DM, or following an @-mention of the bot, e.g.
+// "@mcdowell events" or "hey @mcdowell, help". Messages starting with the
+// bot's name, e.g. "mcdowell leaderboard", and mentions after the start of a
+// message only count when they name a known command, so talking about the bot
+// doesn't get a reply. Files shared without a comment aren't commands either;
+// plugins may want them.
+func (b *Bot) addressedCommand(event *slack.MessageEvent) (string, bool) {
+	text := strings.TrimSpace(event.Text)
+
+	if text == "" && len(event.Files) > 0 {
+		return "", false
+	}
+
+	var addressed, knownOnly bool
+
+	if start, end, ok := b.findMention(text); ok {
+		text = strings.TrimLeft(text[end:], " \t\n:,")
+		addressed = true
+		knownOnly = start > 0
+	} else if name := b.identity.Name; name != "" && len(text) >= len(name) && strings.EqualFold(text[:len(name)], name) {
+		if rest := text[len(name):]; rest == "" || strings.ContainsAny(rest[:1], " \t\n:,") {
+			text = strings.TrimLeft(rest, " \t\n:,")
+			addressed = true
+			knownOnly = true
+		}
+	}
+
+	direct := strings.HasPrefix(event.Channel, "D")
+
+	if !addressed && !direct {
+		return "", false
+	}
+
+	if command, _ := ParseCommand(text); knownOnly && !direct && !b.hasCommand(command) {
+		return "", false
+	}
+
+	// "@mcdowell++" is karma, not a command.
+	if strings.HasPrefix(text, "++") || strings.HasPrefix(text, "--") {
+		return "", false
+	}
+
+	return text, true
+}
+
+// findMention returns where the first @-mention of the bot in text starts
+// and ends, e.g. "<@U0MCDOWELL>" or "<@U0MCDOWELL|mcdowell>".
+func (b *Bot) findMention(text string) (int, int, bool) {
+	if b.identity.UserID == "" {
+		return 0, 0, false
+	}
+
+	prefix := "<@" + b.identity.UserID
+
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], prefix)
+		if i < 0 {
+			return 0, 0, false
+		}
+
+		start := offset + i
+		rest := text[start+len(prefix):]
+
+		if strings.HasPrefix(rest, ">") || strings.HasPrefix(rest, "|") {
+			if closing := strings.Index(rest, ">"); closing >= 0 {
+				return start, start + len(prefix) + closing + 1, true
+			}
+		}
+
+		offset = start + len(prefix)
+	}
+}
+
+// onCommandMessage runs the command in a message addressed to the bot and
+// replies in the same conversation, in the thread if it came from one.
+func (b *Bot) onCommandMessage(event *slack.MessageEvent, text string) error {
+	command, args := ParseCommand(text)
+
+	var userName string
+	if m, ok := b.directory.ByID(event.User); ok {
+		userName = m.Name
+	}
+
+	reply, err := b.RunCommand(&CommandRequest{
+		Command:   command,
+		Args:      args,
+		UserID:    event.User,
+		UserName:  userName,
+		ChannelID: event.Channel,
+	})
+	if err != nil {
+		log.Printf("%q from %s failed: %v\n", text, event.User, err)
+		reply = "Sorry, something went wrong. Please try again later."
+	}
+
+	if reply == "" {
+		return nil
+	}
+
+	options := []slack.MsgOption{
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(reply, false),
+	}
+
+	if event.ThreadTimestamp != "" {
+		options = append(options, slack.MsgOptionTS(event.ThreadTimestamp))
+	}
+
+	_, _, err = b.client.PostMessage(event.Channel, options...)
+
+	return errors.WithStack(err)
+}
@@ -0,0 +1,156 @@
+package mcdowell_test
+
+import (
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+func TestTokenize(t *testing.T) {
+	for text, expected := range map[string][]string{
+		"":                                  nil,
+		"  help  ":                          {"help"},
+		`events add "Tech Happy Hour" 6/29`: {"events", "add", "Tech Happy Hour", "6/29"},
+		"say 'hello there' world":           {"say", "hello there", "world"},
+		"say “curly quotes” please":         {"say", "curly quotes", "please"},
+		`say "" nothing`:                    {"say", "", "nothing"},
+		"don't split apostrophes":           {"don't", "split", "apostrophes"},
+		`say "unterminated quote`:           {"say", "unterminated quote"},
+	} {
+		assert.Equal(t, expected, mcdowell.Tokenize(text), text)
+	}
+}
+
+// greet adds a command to m greeting whoever's named, and returns where the
+// last request for it is kept.
+func greet(t *testing.T, m *mcdowell.Bot) **mcdowell.CommandRequest {
+	t.Helper()
+
+	var got *mcdowell.CommandRequest
+
+	err := m.RegisterCommand(mcdowell.Command{
+		Name:        "greet",
+		Usage:       "greet <name>",
+		Description: "Greets someone the Zamundan way.",
+		Handler: func(b *mcdowell.Bot, req *mcdowell.CommandRequest) (string, error) {
+			got = req
+			return "Greetings, " + req.Args[0] + "!", nil
+		},
+	})
+	assert.Nil(t, err)
+
+	return &got
+}
+
+func say(t *testing.T, m *mcdowell.Bot, channel, text string) {
+	t.Helper()
+
+	err := m.OnNewMessage(&slack.MessageEvent{Msg: slack.Msg{Channel: channel, User: "U0AKEEM", Text: text}})
+	assert.Nil(t, err)
+}
+
+func TestMentionsRunCommands(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+	got := greet(t, m)
+
+	say(t, m, "C0000GENERAL", `<@U0MCDOWELL> greet "Prince Akeem"`)
+
+	assert.Equal(t, "C0000GENERAL", fake.Last().Form.Get("channel"))
+	assert.Equal(t, "Greetings, Prince Akeem!", fake.Last().Form.Get("text"))
+	assert.Equal(t, &mcdowell.CommandRequest{
+		Command:   "greet",
+		Args:      []string{"Prince Akeem"},
+		UserID:    "U0AKEEM",
+		ChannelID: "C0000GENERAL",
+	}, *got)
+
+	say(t, m, "C0000GENERAL", "<@U0MCDOWELL>")
+	assert.Contains(t, fake.Last().Form.Get("text"), "Here's what I can do:")
+	assert.Contains(t, fake.Last().Form.Get("text"), "• `greet <name>` - Greets someone the Zamundan way.")
+	assert.Contains(t, fake.Last().Form.Get("text"), "• `leaderboard` - ")
+
+	say(t, m, "C0000GENERAL", "<@U0MCDOWELL>: dance")
+	assert.Equal(t, "Sorry, I don't know how to `dance`. Try `help` to see what I can do.", fake.Last().Form.Get("text"))
+}
+
+func TestMentionsLaterInAMessageRunKnownCommands(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+	greet(t, m)
+
+	say(t, m, "C0000GENERAL", "hey <@U0MCDOWELL> greet Lisa")
+	assert.Equal(t, "Greetings, Lisa!", fake.Last().Form.Get("text"))
+
+	say(t, m, "C0000GENERAL", "hey <@U0MCDOWELL|mcdowell>, help")
+	assert.Contains(t, fake.Last().Form.Get("text"), "Here's what I can do:")
+
+	fake.Reset()
+
+	say(t, m, "C0000GENERAL", "thanks <@U0MCDOWELL>!")
+	say(t, m, "C0000GENERAL", "have you met <@U0MCDOWELL> yet?")
+	say(t, m, "C0000GENERAL", "<@U0MCDOWELLS> greet Lisa")
+	assert.Empty(t, fake.All())
+}
+
+func TestDirectMessagesRunCommands(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+	greet(t, m)
+
+	say(t, m, "D0AKEEM", "greet Semmi")
+
+	assert.Equal(t, "D0AKEEM", fake.Last().Form.Get("channel"))
+	assert.Equal(t, "Greetings, Semmi!", fake.Last().Form.Get("text"))
+}
+
+func TestCommandRepliesStayInThreads(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+	greet(t, m)
+
+	err := m.OnNewMessage(&slack.MessageEvent{Msg: slack.Msg{
+		Channel:         "C0000GENERAL",
+		User:            "U0AKEEM",
+		Text:            "<@U0MCDOWELL> greet Lisa",
+		ThreadTimestamp: "123.456",
+	}})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "123.456", fake.Last().Form.Get("thread_ts"))
+}
+
+func TestBotNameRunsKnownCommands(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+	greet(t, m)
+
+	say(t, m, "C0000GENERAL", "McDowell greet Darryl")
+	assert.Equal(t, "Greetings, Darryl!", fake.Last().Form.Get("text"))
+
+	fake.Reset()
+
+	say(t, m, "C0000GENERAL", "mcdowell is a great restaurant")
+	say(t, m, "C0000GENERAL", "McDowell's has golden arcs")
+	assert.Empty(t, fake.All())
+}
+
+func TestKarmaForTheBotIsNotACommand(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+	greet(t, m)
+
+	say(t, m, "C0000GENERAL", "<@U0MCDOWELL>++")
+	assert.Equal(t, "<@U0MCDOWELL>’s karma is now 1.", fake.Last().Form.Get("text"))
+}
+
+func TestSharedFilesAreNotCommands(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil)
+	greet(t, m)
+
+	err := m.OnNewMessage(&slack.MessageEvent{Msg: slack.Msg{
+		Channel: "D0AKEEM",
+		User:    "U0AKEEM",
+		Files:   []slack.File{{Name: "meetups.ics"}},
+	}})
+	assert.Nil(t, err)
+
+	assert.Empty(t, fake.Last().Form.Get("text"))
+}
@@ -0,0 +1,568 @@
+// Package mentorship pairs members who want to mentor with members looking
+// for a mentor. Members sign up with "mcdowell mentorship mentor golang
+// evenings" or "mcdowell mentorship mentee ...", and matching, run by an
+// admin or on a schedule, introduces each pair in a group DM. Either of them
+// ends the pairing with "mcdowell mentorship done".
+package mentorship
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+	"github.com/willmadison/mcdowell"
+)
+
+// Name is the mentorship plugin's name, and its section under "plugins" in
+// the bot's config.
+const Name = "mentorship"
+
+// DefaultMaxMentees is how many mentees a mentor is paired with at once
+// unless configured otherwise.
+const DefaultMaxMentees = 2
+
+// registrationsBucket is where sign ups are stored, keyed by user ID.
+const registrationsBucket = "mentorship"
+
+// matchJob is the scheduled job that runs matching.
+const matchJob = "mentorship:match"
+
+// Roles members can sign up for.
+const (
+	Mentor = "mentor"
+	Mentee = "mentee"
+)
+
+// Slots are the times members can say they're available.
+var Slots = []string{"mornings", "afternoons", "evenings", "weekends"}
+
+// mentionPattern matches a mention of a member, e.g. "<@U0LISA>" or "<@U0LISA|lisa>".
+var mentionPattern = regexp.MustCompile(`^<@([UW][A-Z0-9]+)(?:\|[^>]*)?>$`)
+
+const usage = "Usage: mentorship mentor|mentee skill... when...\n" +
+	"e.g. `mentorship mentee golang career-growth evenings weekends`. " +
+	"You can be available mornings, afternoons, evenings or weekends."
+
+type (
+	// Config is the mentorship section of the bot's config. Matching runs on
+	// Schedule, a cron expression, as well as when admins ask.
+	Config struct {
+		Schedule   string `json:"schedule,omitempty"`
+		MaxMentees int    `json:"max_mentees,omitempty"`
+	}
+
+	// Registration is a member's sign up as a mentor or mentee.
+	Registration struct {
+		User         string    `json:"user"`
+		Role         string    `json:"role"`
+		Tags         []string  `json:"tags"`
+		Availability []string  `json:"availability"`
+		Registered   time.Time `json:"registered"`
+
+		// Paired are who the member is paired with now, and Matches everyone
+		// they've ever been paired with, so no pair is matched twice.
+		Paired  []string `json:"paired,omitempty"`
+		Matches []string `json:"matches,omitempty"`
+	}
+
+	// Match is a mentor and mentee paired up, along with what they have in common.
+	Match struct {
+		Mentor, Mentee Registration
+		Tags           []string
+		Availability   []string
+	}
+
+	// Program is the mentorship plugin.
+	Program struct {
+		maxMentees int
+
+		// mu keeps sign ups, pairings ending and matching, from commands and
+		// the schedule, from overwriting each other's changes.
+		mu sync.Mutex
+	}
+)
+
+// New creates the mentorship plugin.
+func New() *Program {
+	return &Program{}
+}
+
+// Name implements mcdowell.Plugin.
+func (p *Program) Name() string {
+	return Name
+}
+
+// Init implements mcdowell.Plugin.
+func (p *Program) Init(b *mcdowell.Bot) error {
+	var config Config
+
+	err := b.PluginConfig(Name, &config)
+	if err != nil && err != mcdowell.ErrNotFound {
+		return err
+	}
+
+	p.maxMentees = config.MaxMentees
+	if p.maxMentees <= 0 {
+		p.maxMentees = DefaultMaxMentees
+	}
+
+	if config.Schedule != "" {
+		err = b.RegisterJob(mcdowell.Job{Name: matchJob, Schedule: config.Schedule, Run: func(b *mcdowell.Bot, last time.Time) error {
+			_, err := p.Match(b)
+			return err
+		}})
+		if err != nil {
+			return err
+		}
+	}
+
+	return b.RegisterCommand(mcdowell.Command{
+		Name:        "mentorship",
+		Usage:       "mentorship [mentor|mentee skill... when... | done [@member] | leave | match]",
+		Description: "Signs you up to mentor or be mentored, shows your sign up, or ends a pairing. Admins can match everyone who's waiting.",
+		Handler:     p.command,
+	})
+}
+
+// Handlers implements mcdowell.Plugin.
+func (p *Program) Handlers() map[string]mcdowell.Handler {
+	return nil
+}
+
+func (p *Program) command(b *mcdowell.Bot, req *mcdowell.CommandRequest) (string, error) {
+	if len(req.Args) == 0 {
+		return p.status(b, req.UserID)
+	}
+
+	switch role := strings.ToLower(req.Args[0]); role {
+	case Mentor, Mentee:
+		return p.register(b, req.UserID, role, req.Args[1:])
+	case "done":
+		return p.done(b, req.UserID, req.Args[1:])
+	case "leave":
+		return p.leave(b, req.UserID)
+	case "match":
+		if !b.IsAdmin(req.UserID) {
+			return "Sorry, only admins can run matching.", nil
+		}
+
+		matches, err := p.Match(b)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("Matched %d %s.", len(matches), plural(len(matches), "pair", "pairs")), nil
+	default:
+		return usage, nil
+	}
+}
+
+// register signs userID up as role, replacing any earlier sign up but
+// keeping track of who they've been matched with. Mentors updating their sign
+// up stay paired with their mentees; anyone else signing up again is looking
+// for someone new, so their pairings end.
+func (p *Program) register(b *mcdowell.Bot, userID, role string, args []string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r := Registration{User: userID, Role: role, Registered: b.Now()}
+
+	for _, arg := range args {
+		for _, word := range strings.FieldsFunc(strings.ToLower(arg), func(r rune) bool { return r == ',' || r == ' ' }) {
+			word = strings.Trim(word, "#")
+
+			switch {
+			case word == "":
+			case isSlot(word):
+				r.Availability = appendUnique(r.Availability, word)
+			default:
+				r.Tags = appendUnique(r.Tags, word)
+			}
+		}
+	}
+
+	if len(r.Tags) == 0 || len(r.Availability) == 0 {
+		return usage, nil
+	}
+
+	sort.Strings(r.Tags)
+	sort.Strings(r.Availability)
+
+	existing, err := get(b.Store(), userID)
+	if err != nil && err != mcdowell.ErrNotFound {
+		return "", err
+	}
+
+	if err == nil {
+		r.Matches = existing.Matches
+
+		if role == Mentor && existing.Role == Mentor {
+			r.Paired = existing.Paired
+		} else if err := unpair(b.Store(), existing, existing.Paired...); err != nil {
+			return "", err
+		}
+	}
+
+	if err := put(b.Store(), r); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("You’re signed up as a %s for %s, available %s. I’ll introduce you when I find a match!",
+		role, strings.Join(r.Tags, ", "), strings.Join(r.Availability, ", ")), nil
+}
+
+func (p *Program) status(b *mcdowell.Bot, userID string) (string, error) {
+	r, err := get(b.Store(), userID)
+	if err == mcdowell.ErrNotFound {
+		return "You’re not signed up for mentorship. " + usage, nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	text := fmt.Sprintf("You’re signed up as a %s for %s, available %s.", r.Role, strings.Join(r.Tags, ", "), strings.Join(r.Availability, ", "))
+
+	if len(r.Paired) > 0 {
+		text += " You’re paired with " + mentions(r.Paired) + "."
+	}
+
+	return text, nil
+}
+
+// done ends userID's pairing with the member mentioned in args, or with the
+// only member they're paired with.
+func (p *Program) done(b *mcdowell.Bot, userID string, args []string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r, err := get(b.Store(), userID)
+	if err == mcdowell.ErrNotFound || (err == nil && len(r.Paired) == 0) {
+		return "You’re not paired with anyone.", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	var partner string
+
+	switch {
+	case len(args) > 0:
+		if m := mentionPattern.FindStringSubmatch(args[0]); m != nil {
+			partner = m[1]
+		}
+
+		if !contains(r.Paired, partner) {
+			return "You’re paired with " + mentions(r.Paired) + ". Who are you done with?", nil
+		}
+	case len(r.Paired) == 1:
+		partner = r.Paired[0]
+	default:
+		return "You’re paired with " + mentions(r.Paired) + ". Who are you done with? e.g. `mentorship done @someone`", nil
+	}
+
+	if err := unpair(b.Store(), r, partner); err != nil {
+		return "", err
+	}
+
+	r.Paired = remove(r.Paired, partner)
+
+	if err := put(b.Store(), r); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Your pairing with <@%s> is over. Thanks for taking part!", partner), nil
+}
+
+// leave takes userID off the list, ending their pairings.
+func (p *Program) leave(b *mcdowell.Bot, userID string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	r, err := get(b.Store(), userID)
+	if err == mcdowell.ErrNotFound {
+		return "You’re not signed up for mentorship.", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := unpair(b.Store(), r, r.Paired...); err != nil {
+		return "", err
+	}
+
+	if err := b.Store().Delete(registrationsBucket, userID); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	return "You’re no longer signed up for mentorship.", nil
+}
+
+// unpair ends r's pairings with partners on the partners' side.
+func unpair(store mcdowell.Store, r Registration, partners ...string) error {
+	for _, id := range partners {
+		partner, err := get(store, id)
+		if err == mcdowell.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		partner.Paired = remove(partner.Paired, r.User)
+
+		if err := put(store, partner); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Match pairs each mentee who isn't paired with the mentor they share the
+// most skills with, among mentors with room for another mentee who are free
+// at the same time and haven't been paired with them before, and introduces
+// each pair in a group DM. Mentees who've waited longest are matched first.
+func (p *Program) Match(b *mcdowell.Bot) ([]Match, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	registrations, err := Registrations(b.Store())
+	if err != nil {
+		return nil, err
+	}
+
+	var mentors, mentees []*Registration
+
+	for i := range registrations {
+		r := &registrations[i]
+
+		if m, ok := b.Directory().ByID(r.User); ok && m.Deleted {
+			continue
+		}
+
+		switch {
+		case r.Role == Mentor:
+			mentors = append(mentors, r)
+		case r.Role == Mentee && len(r.Paired) == 0:
+			mentees = append(mentees, r)
+		}
+	}
+
+	var matches []Match
+
+	for _, mentee := range mentees {
+		var (
+			best      *Registration
+			bestMatch Match
+		)
+
+		for _, mentor := range mentors {
+			if mentor.User == mentee.User || len(mentor.Paired) >= p.maxMentees || contains(mentor.Matches, mentee.User) {
+				continue
+			}
+
+			m := Match{Mentor: *mentor, Mentee: *mentee, Tags: overlap(mentor.Tags, mentee.Tags), Availability: overlap(mentor.Availability, mentee.Availability)}
+			if len(m.Tags) == 0 || len(m.Availability) == 0 {
+				continue
+			}
+
+			if best == nil || better(m, bestMatch) {
+				best, bestMatch = mentor, m
+			}
+		}
+
+		if best == nil {
+			continue
+		}
+
+		if err := p.introduce(b, bestMatch); err != nil {
+			return matches, err
+		}
+
+		best.Paired = append(best.Paired, mentee.User)
+		best.Matches = appendUnique(best.Matches, mentee.User)
+		mentee.Paired = append(mentee.Paired, best.User)
+		mentee.Matches = appendUnique(mentee.Matches, best.User)
+
+		if err := put(b.Store(), *best); err != nil {
+			return matches, err
+		}
+
+		if err := put(b.Store(), *mentee); err != nil {
+			return matches, err
+		}
+
+		matches = append(matches, bestMatch)
+	}
+
+	return matches, nil
+}
+
+// better reports whether m is a better match than other: more skills in
+// common, then more time in common, then a mentor with fewer mentees, then
+// whoever signed up first.
+func better(m, other Match) bool {
+	switch {
+	case len(m.Tags) != len(other.Tags):
+		return len(m.Tags) > len(other.Tags)
+	case len(m.Availability) != len(other.Availability):
+		return len(m.Availability) > len(other.Availability)
+	case len(m.Mentor.Paired) != len(other.Mentor.Paired):
+		return len(m.Mentor.Paired) < len(other.Mentor.Paired)
+	default:
+		return m.Mentor.Registered.Before(other.Mentor.Registered)
+	}
+}
+
+// introduce opens a group DM with the pair in m and introduces them.
+func (p *Program) introduce(b *mcdowell.Bot, m Match) error {
+	channel, _, _, err := b.Client().OpenConversation(&slack.OpenConversationParameters{
+		Users: []string{m.Mentor.User, m.Mentee.User},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to open a DM with %s and %s", m.Mentor.User, m.Mentee.User)
+	}
+
+	text := fmt.Sprintf("<@%s>, meet <@%s>, your new mentor! :handshake: You’re both into %s and free %s. "+
+		"Why not set up a time to chat and get to know each other?",
+		m.Mentee.User, m.Mentor.User, join(m.Tags), join(m.Availability))
+
+	_, _, err = b.Client().PostMessage(channel.ID,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(text, false),
+	)
+
+	return errors.Wrapf(err, "unable to introduce %s and %s", m.Mentor.User, m.Mentee.User)
+}
+
+// Registrations returns every sign up in store, oldest first.
+func Registrations(store mcdowell.Store) ([]Registration, error) {
+	ids, err := store.Keys(registrationsBucket)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var registrations []Registration
+
+	for _, id := range ids {
+		r, err := get(store, id)
+		if err == mcdowell.ErrNotFound {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		registrations = append(registrations, r)
+	}
+
+	sort.SliceStable(registrations, func(i, j int) bool {
+		return registrations[i].Registered.Before(registrations[j].Registered)
+	})
+
+	return registrations, nil
+}
+
+func get(store mcdowell.Store, id string) (Registration, error) {
+	var r Registration
+
+	value, err := store.Get(registrationsBucket, id)
+	if err != nil {
+		return r, err
+	}
+
+	return r, errors.Wrapf(json.Unmarshal(value, &r), "corrupt mentorship sign up %s", id)
+}
+
+func put(store mcdowell.Store, r Registration) error {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return store.Put(registrationsBucket, r.User, value)
+}
+
+func isSlot(word string) bool {
+	return contains(Slots, word)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendUnique(values []string, value string) []string {
+	if contains(values, value) {
+		return values
+	}
+
+	return append(values, value)
+}
+
+// remove returns values without value.
+func remove(values []string, value string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != value {
+			kept = append(kept, v)
+		}
+	}
+
+	return kept
+}
+
+// overlap returns the values in both a and b, in a's order.
+func overlap(a, b []string) []string {
+	var both []string
+	for _, v := range a {
+		if contains(b, v) {
+			both = append(both, v)
+		}
+	}
+
+	return both
+}
+
+// join lists values in a sentence, e.g. "golang, react and devops".
+func join(values []string) string {
+	if len(values) < 2 {
+		return strings.Join(values, "")
+	}
+
+	return strings.Join(values[:len(values)-1], ", ") + " and " + values[len(values)-1]
+}
+
+// mentions lists the members with ids in a sentence, e.g. "<@U0LISA> and <@U0SEMMI>".
+func mentions(ids []string) string {
+	var mentioned []string
+	for _, id := range ids {
+		mentioned = append(mentioned, "<@"+id+">")
+	}
+
+	return join(mentioned)
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+
+	return many
+}
@@ -0,0 +1,173 @@
+package mentorship_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+	"github.com/willmadison/mcdowell/mentorship"
+)
+
+const admin = slacktest.Admin
+
+func newMentorshipBot(t *testing.T, config string) (*mcdowell.Bot, *slacktest.Server, *slacktest.Clock) {
+	t.Helper()
+
+	return slacktest.NewBot(t, config, map[string]string{
+		"users.list":         `{"ok": true, "members": [{"id": "U0WILL", "name": "willmadison"}, {"id": "U0GONE", "name": "gone", "deleted": true}]}`,
+		"conversations.open": `{"ok": true, "channel": {"id": "G0PAIR"}}`,
+	}, mcdowell.WithPlugin(mentorship.New()))
+}
+
+func TestSigningUpForMentorship(t *testing.T) {
+	b, _, _ := newMentorshipBot(t, `{}`)
+
+	assert.True(t, strings.HasPrefix(slacktest.Run(t, b, "U0AKEEM", "mentorship"), "You’re not signed up for mentorship. Usage:"))
+	assert.True(t, strings.HasPrefix(slacktest.Run(t, b, "U0AKEEM", "mentorship", "mentee", "golang"), "Usage:"))
+	assert.True(t, strings.HasPrefix(slacktest.Run(t, b, "U0AKEEM", "mentorship", "mentee", "evenings"), "Usage:"))
+	assert.True(t, strings.HasPrefix(slacktest.Run(t, b, "U0AKEEM", "mentorship", "royalty"), "Usage:"))
+
+	assert.Equal(t, "You’re signed up as a mentee for career-growth, golang, available evenings, weekends. I’ll introduce you when I find a match!",
+		slacktest.Run(t, b, "U0AKEEM", "mentorship", "mentee", "Golang,", "#career-growth", "weekends", "evenings", "golang"))
+	assert.Equal(t, "You’re signed up as a mentee for career-growth, golang, available evenings, weekends.", slacktest.Run(t, b, "U0AKEEM", "mentorship"))
+
+	assert.Equal(t, "You’re signed up as a mentor for react, available mornings. I’ll introduce you when I find a match!",
+		slacktest.Run(t, b, "U0AKEEM", "mentorship", "mentor", "react", "mornings"))
+
+	assert.Equal(t, "You’re no longer signed up for mentorship.", slacktest.Run(t, b, "U0AKEEM", "mentorship", "leave"))
+	assert.Equal(t, "You’re not signed up for mentorship.", slacktest.Run(t, b, "U0AKEEM", "mentorship", "leave"))
+}
+
+func TestMatchingMentorsAndMentees(t *testing.T) {
+	b, fake, c := newMentorshipBot(t, `{}`)
+
+	signUp := func(user string, args ...string) {
+		c.Add(time.Minute)
+		slacktest.Run(t, b, user, "mentorship", args...)
+	}
+
+	signUp("U0LISA", "mentor", "golang", "career-growth", "evenings")
+	signUp("U0DARRYL", "mentor", "react", "weekends")
+	signUp("U0SEMMI", "mentor", "golang", "mornings")
+	signUp("U0GONE", "mentor", "golang", "evenings", "mornings")
+
+	signUp("U0AKEEM", "mentee", "golang", "career-growth", "evenings", "weekends")
+	signUp("U0PATRICE", "mentee", "react", "evenings")
+	signUp("U0OHA", "mentee", "golang", "mornings", "evenings")
+
+	assert.Equal(t, "Sorry, only admins can run matching.", slacktest.Run(t, b, "U0AKEEM", "mentorship", "match"))
+	assert.Empty(t, fake.Calls("conversations.open"))
+
+	assert.Equal(t, "Matched 2 pairs.", slacktest.Run(t, b, admin, "mentorship", "match"))
+
+	opened := fake.Calls("conversations.open")
+	assert.Len(t, opened, 2)
+	assert.Equal(t, "U0LISA,U0AKEEM", opened[0].Form.Get("users"))
+	assert.Equal(t, "U0SEMMI,U0OHA", opened[1].Form.Get("users"))
+
+	posted := fake.Calls("chat.postMessage")
+	assert.Len(t, posted, 2)
+	assert.Equal(t, "G0PAIR", posted[0].Form.Get("channel"))
+	assert.Equal(t, "<@U0AKEEM>, meet <@U0LISA>, your new mentor! :handshake: You’re both into career-growth and golang and free evenings. Why not set up a time to chat and get to know each other?", posted[0].Form.Get("text"))
+
+	assert.Equal(t, "You’re signed up as a mentee for career-growth, golang, available evenings, weekends. You’re paired with <@U0LISA>.", slacktest.Run(t, b, "U0AKEEM", "mentorship"))
+	assert.Contains(t, slacktest.Run(t, b, "U0SEMMI", "mentorship"), "You’re paired with <@U0OHA>.")
+
+	// Matched mentees aren't matched again.
+	fake.Reset()
+
+	assert.Equal(t, "Matched 0 pairs.", slacktest.Run(t, b, admin, "mentorship", "match"))
+	assert.Empty(t, fake.Calls("conversations.open"))
+
+	// Until someone who fits signs up.
+	signUp("U0DARRYL", "mentor", "react", "weekends", "evenings")
+
+	assert.Equal(t, "Matched 1 pair.", slacktest.Run(t, b, admin, "mentorship", "match"))
+	assert.Equal(t, "U0DARRYL,U0PATRICE", fake.Calls("conversations.open")[0].Form.Get("users"))
+}
+
+func TestMentorsAreNotOverloaded(t *testing.T) {
+	b, fake, c := newMentorshipBot(t, `{"plugins": {"mentorship": {"max_mentees": 1, "schedule": "0 9 * * MON"}}}`)
+
+	slacktest.Run(t, b, "U0LISA", "mentorship", "mentor", "golang", "evenings")
+	slacktest.Run(t, b, "U0AKEEM", "mentorship", "mentee", "golang", "evenings")
+	slacktest.Run(t, b, "U0OHA", "mentorship", "mentee", "golang", "evenings")
+
+	// Matching runs on Monday mornings.
+	c.Set(time.Date(2019, time.June, 3, 13, 0, 0, 0, time.UTC))
+	assert.Nil(t, b.RunDueJobs())
+
+	opened := fake.Calls("conversations.open")
+	assert.Len(t, opened, 1)
+	assert.Equal(t, "U0LISA,U0AKEEM", opened[0].Form.Get("users"))
+
+	assert.True(t, strings.HasPrefix(slacktest.Run(t, b, "U0OHA", "mentorship"), "You’re signed up as a mentee"))
+	assert.NotContains(t, slacktest.Run(t, b, "U0OHA", "mentorship"), "paired")
+}
+
+func TestEndingPairings(t *testing.T) {
+	b, fake, _ := newMentorshipBot(t, `{"plugins": {"mentorship": {"max_mentees": 1}}}`)
+
+	slacktest.Run(t, b, "U0LISA", "mentorship", "mentor", "golang", "evenings")
+	slacktest.Run(t, b, "U0AKEEM", "mentorship", "mentee", "golang", "evenings")
+	slacktest.Run(t, b, "U0OHA", "mentorship", "mentee", "golang", "evenings")
+
+	assert.Equal(t, "You’re not paired with anyone.", slacktest.Run(t, b, "U0AKEEM", "mentorship", "done"))
+	assert.Equal(t, "Matched 1 pair.", slacktest.Run(t, b, admin, "mentorship", "match"))
+
+	assert.Equal(t, "You’re paired with <@U0AKEEM>. Who are you done with?", slacktest.Run(t, b, "U0LISA", "mentorship", "done", "<@U0OHA>"))
+	assert.Equal(t, "Your pairing with <@U0LISA> is over. Thanks for taking part!", slacktest.Run(t, b, "U0AKEEM", "mentorship", "done"))
+	assert.NotContains(t, slacktest.Run(t, b, "U0LISA", "mentorship"), "paired")
+
+	// Ending a pairing makes room for another mentee, but pairs aren't matched twice.
+	fake.Reset()
+
+	assert.Equal(t, "Matched 1 pair.", slacktest.Run(t, b, admin, "mentorship", "match"))
+	assert.Equal(t, "U0LISA,U0OHA", fake.Calls("conversations.open")[0].Form.Get("users"))
+
+	// Mentees signing up again are looking for someone new.
+	slacktest.Run(t, b, "U0OHA", "mentorship", "mentee", "golang", "evenings", "weekends")
+	assert.NotContains(t, slacktest.Run(t, b, "U0OHA", "mentorship"), "paired")
+	assert.NotContains(t, slacktest.Run(t, b, "U0LISA", "mentorship"), "paired")
+
+	// Mentors updating their sign up stay paired, and leaving ends the pairing.
+	slacktest.Run(t, b, "U0SEMMI", "mentorship", "mentor", "golang", "evenings")
+	assert.Equal(t, "Matched 1 pair.", slacktest.Run(t, b, admin, "mentorship", "match"))
+
+	slacktest.Run(t, b, "U0SEMMI", "mentorship", "mentor", "golang", "evenings", "weekends")
+	assert.Contains(t, slacktest.Run(t, b, "U0SEMMI", "mentorship"), "You’re paired with <@U0AKEEM>.")
+
+	slacktest.Run(t, b, "U0SEMMI", "mentorship", "leave")
+	assert.NotContains(t, slacktest.Run(t, b, "U0AKEEM", "mentorship"), "paired")
+}
+
+func TestMatchingAtOnceIntroducesEachPairOnce(t *testing.T) {
+	b, fake, _ := newMentorshipBot(t, `{"plugins": {"mentorship": {"max_mentees": 8}}}`)
+
+	slacktest.Run(t, b, "U0LISA", "mentorship", "mentor", "golang", "evenings")
+
+	for i := 0; i < 8; i++ {
+		slacktest.Run(t, b, fmt.Sprintf("U0MENTEE%d", i), "mentorship", "mentee", "golang", "evenings")
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			slacktest.Run(t, b, admin, "mentorship", "match")
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 8, len(fake.Calls("conversations.open")), "each pair should be introduced once")
+	assert.Contains(t, slacktest.Run(t, b, "U0LISA", "mentorship"), "You’re paired with <@U0MENTEE0>, <@U0MENTEE1>")
+}
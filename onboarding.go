@@ -0,0 +1,196 @@
+package mcdowell
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// Block Kit identifiers used by the onboarding DM.
+const (
+	onboardingInterestsBlock = "onboarding_interests"
+	onboardingInterestAction = "onboarding_interest"
+	onboardingDoneAction     = "onboarding_done"
+)
+
+type (
+	// OnboardingConfig describes the interests newcomers can pick from in their
+	// welcome DM, and the channels each one gets them added to.
+	OnboardingConfig struct {
+		Prompt    string     `json:"prompt,omitempty"`
+		Interests []Interest `json:"interests"`
+	}
+
+	// Interest is one of the sub-communities a newcomer can say they're into.
+	// Channels can be given by ID or name.
+	Interest struct {
+		Name     string   `json:"name"`
+		Label    string   `json:"label"`
+		Channels []string `json:"channels"`
+	}
+)
+
+const defaultOnboardingPrompt = "What are you into? Pick as many as you like and I’ll add you to the right channels."
+
+func (c *OnboardingConfig) validate() error {
+	if len(c.Interests) == 0 {
+		return errors.New("no interests")
+	}
+
+	seen := map[string]bool{}
+
+	for i, interest := range c.Interests {
+		if interest.Name == "" || interest.Label == "" {
+			return errors.Errorf("interest #%d needs a name and a label", i+1)
+		}
+
+		if seen[interest.Name] {
+			return errors.Errorf("interest %q is listed twice", interest.Name)
+		}
+
+		seen[interest.Name] = true
+
+		if len(interest.Channels) == 0 {
+			return errors.Errorf("interest %q has no channels", interest.Name)
+		}
+	}
+
+	return nil
+}
+
+func (b *Bot) onboarding() *OnboardingConfig {
+	if b.config == nil {
+		return nil
+	}
+
+	return b.config.Onboarding
+}
+
+// resolveOnboardingChannels looks up the IDs of the channels each interest
+// is linked to.
+func (b *Bot) resolveOnboardingChannels(ids map[string]string) {
+	onboarding := b.onboarding()
+	if onboarding == nil {
+		return
+	}
+
+	b.interestChannels = map[string][]string{}
+
+	for _, interest := range onboarding.Interests {
+		for _, channel := range interest.Channels {
+			if id, ok := resolveChannel(channel, ids); ok {
+				b.interestChannels[interest.Name] = append(b.interestChannels[interest.Name], id)
+			} else {
+				log.Printf("unable to resolve #%s for %s newcomers, skipping it\n", normalizeChannelName(channel), interest.Name)
+			}
+		}
+	}
+}
+
+// onboardingBlocks builds the welcome DM: message followed by a button for
+// each interest and one to finish up.
+func (b *Bot) onboardingBlocks(message string) []slack.Block {
+	onboarding := b.onboarding()
+
+	prompt := onboarding.Prompt
+	if prompt == "" {
+		prompt = defaultOnboardingPrompt
+	}
+
+	var buttons []slack.BlockElement
+	for _, interest := range onboarding.Interests {
+		buttons = append(buttons, slack.NewButtonBlockElement(onboardingInterestAction+":"+interest.Name, interest.Name,
+			slack.NewTextBlockObject(slack.PlainTextType, interest.Label, false, false)))
+	}
+
+	done := slack.NewButtonBlockElement(onboardingDoneAction, "done",
+		slack.NewTextBlockObject(slack.PlainTextType, "I’m all set", false, false))
+	done.Style = slack.StylePrimary
+
+	buttons = append(buttons, done)
+
+	return []slack.Block{
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, message, false, false), nil, nil),
+		slack.NewDividerBlock(),
+		slack.NewSectionBlock(slack.NewTextBlockObject(slack.MarkdownType, prompt, false, false), nil, nil),
+		slack.NewActionBlock(onboardingInterestsBlock, buttons...),
+	}
+}
+
+// onInteraction handles people clicking buttons in the bot's messages.
+func (b *Bot) onInteraction(callback *slack.InteractionCallback) error {
+	if callback.Type != slack.InteractionTypeBlockActions {
+		return nil
+	}
+
+	var err error
+
+	for _, action := range callback.ActionCallback.BlockActions {
+		switch {
+		case strings.HasPrefix(action.ActionID, onboardingInterestAction+":"):
+			err = b.onInterestPicked(callback, action.Value)
+		case action.ActionID == onboardingDoneAction:
+			err = b.onOnboardingDone(callback)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *Bot) onInterestPicked(callback *slack.InteractionCallback, name string) error {
+	onboarding := b.onboarding()
+	if onboarding == nil {
+		return nil
+	}
+
+	var label string
+	for _, interest := range onboarding.Interests {
+		if interest.Name == name {
+			label = interest.Label
+		}
+	}
+
+	if label == "" {
+		return errors.Errorf("unknown interest %q", name)
+	}
+
+	var joined []string
+
+	for _, channel := range b.interestChannels[name] {
+		_, err := b.client.InviteUsersToConversation(channel, callback.User.ID)
+		if err != nil && err.Error() != "already_in_channel" {
+			log.Printf("unable to invite %s to %s: %v\n", callback.User.ID, channel, err)
+			continue
+		}
+
+		joined = append(joined, "<#"+channel+">")
+	}
+
+	message := fmt.Sprintf("Sorry, I couldn’t add you to the %s channels. Click on “Channels” to find them yourself.", label)
+	if len(joined) > 0 {
+		message = fmt.Sprintf("%s it is! I’ve added you to %s. Pick another or let me know when you’re all set.", label, strings.Join(joined, ", "))
+	}
+
+	_, _, err := b.client.PostMessage(callback.Channel.ID,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(message, false),
+	)
+
+	return err
+}
+
+func (b *Bot) onOnboardingDone(callback *slack.InteractionCallback) error {
+	_, _, err := b.client.PostMessage(callback.Channel.ID,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText("You’re all set! You can always click on “Channels” to find more of our sub-communities. Welcome to the family!", false),
+	)
+
+	return err
+}
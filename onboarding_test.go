@@ -0,0 +1,155 @@
+package mcdowell_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+const onboardingConfig = `{
+	"onboarding": {
+		"interests": [
+			{"name": "engineering", "label": "Engineering", "channels": ["#engineering", "C0000GOLANG"]},
+			{"name": "design", "label": "Design", "channels": ["#design"]},
+			{"name": "founders", "label": "Founders", "channels": ["#founders"]},
+			{"name": "jobs", "label": "Jobs", "channels": ["#jobs"]}
+		]
+	}
+}`
+
+// onboardingChannels are the channels interests lead to.
+var onboardingChannels = map[string]string{
+	"conversations.list": `{
+		"ok": true,
+		"channels": [
+			{"id": "C0000ENGINE", "name": "engineering"},
+			{"id": "C0000DESIGN", "name": "design"},
+			{"id": "C00FOUNDERS", "name": "founders"},
+			{"id": "C000000JOBS", "name": "jobs"}
+		]
+	}`,
+	"conversations.invite": `{"ok": true, "channel": {"id": "C0000ENGINE"}}`,
+}
+
+func interaction(t *testing.T, payload string) *http.Request {
+	t.Helper()
+
+	form := url.Values{"payload": {payload}}
+
+	return signedRequest(t, "/slack/interactions", testSigningSecret, "application/x-www-form-urlencoded", form.Encode())
+}
+
+func TestOnboardingWelcomeOffersInterests(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, onboardingConfig, onboardingChannels, mcdowell.WithSigningSecret(testSigningSecret))
+
+	err := m.OnTeamJoined(&slack.TeamJoinEvent{
+		User: slack.User{ID: "U0SEMMI", Name: "Semmi"},
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "U0SEMMI", fake.Last().Form.Get("channel"))
+	assert.Contains(t, fake.Last().Form.Get("text"), "Yo Semmi!")
+
+	var blocks []struct {
+		Type     string `json:"type"`
+		BlockID  string `json:"block_id"`
+		Elements []struct {
+			ActionID string `json:"action_id"`
+			Value    string `json:"value"`
+			Text     struct {
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"elements"`
+	}
+
+	err = json.Unmarshal([]byte(fake.Last().Form.Get("blocks")), &blocks)
+	assert.Nil(t, err)
+
+	var labels, values []string
+	for _, block := range blocks {
+		if block.Type != "actions" {
+			continue
+		}
+
+		for _, element := range block.Elements {
+			labels = append(labels, element.Text.Text)
+			values = append(values, element.Value)
+		}
+	}
+
+	assert.Equal(t, []string{"Engineering", "Design", "Founders", "Jobs", "I’m all set"}, labels)
+	assert.Equal(t, []string{"engineering", "design", "founders", "jobs", "done"}, values)
+}
+
+func TestOnboardingInterestClicksInviteToChannels(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, onboardingConfig, onboardingChannels, mcdowell.WithSigningSecret(testSigningSecret))
+
+	w := httptest.NewRecorder()
+	m.HandleInteraction(w, interaction(t, `{
+		"type": "block_actions",
+		"user": {"id": "U0SEMMI", "name": "semmi"},
+		"channel": {"id": "D0SEMMI"},
+		"actions": [
+			{
+				"action_id": "onboarding_interest:engineering",
+				"block_id": "onboarding_interests",
+				"type": "button",
+				"value": "engineering"
+			}
+		]
+	}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, []string{"conversations.invite", "conversations.invite", "chat.postMessage"}, fake.Methods())
+	assert.Equal(t, "C0000ENGINE", fake.All()[0].Form.Get("channel"))
+	assert.Equal(t, "U0SEMMI", fake.All()[0].Form.Get("users"))
+	assert.Equal(t, "C0000GOLANG", fake.All()[1].Form.Get("channel"))
+	assert.Equal(t, "D0SEMMI", fake.All()[2].Form.Get("channel"))
+	assert.Equal(t, "Engineering it is! I’ve added you to <#C0000ENGINE>, <#C0000GOLANG>. Pick another or let me know when you’re all set.", fake.All()[2].Form.Get("text"))
+
+	fake.Reset()
+
+	w = httptest.NewRecorder()
+	m.HandleInteraction(w, interaction(t, `{
+		"type": "block_actions",
+		"user": {"id": "U0SEMMI", "name": "semmi"},
+		"channel": {"id": "D0SEMMI"},
+		"actions": [{"action_id": "onboarding_done", "block_id": "onboarding_interests", "type": "button", "value": "done"}]
+	}`))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"chat.postMessage"}, fake.Methods())
+	assert.Contains(t, fake.Last().Form.Get("text"), "You’re all set!")
+}
+
+func TestInteractionsRejectBadSignatures(t *testing.T) {
+	m, _, _ := slacktest.NewBot(t, onboardingConfig, onboardingChannels, mcdowell.WithSigningSecret(testSigningSecret))
+
+	r := interaction(t, `{"type": "block_actions"}`)
+	r.Header.Set("X-Slack-Signature", "v0=deadbeef")
+
+	w := httptest.NewRecorder()
+	m.HandleInteraction(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestInvalidOnboardingConfigIsRejected(t *testing.T) {
+	for _, config := range []string{
+		`{"onboarding": {"interests": []}}`,
+		`{"onboarding": {"interests": [{"name": "design", "channels": ["#design"]}]}}`,
+		`{"onboarding": {"interests": [{"name": "design", "label": "Design"}]}}`,
+		`{"onboarding": {"interests": [{"name": "design", "label": "Design", "channels": ["#design"]}, {"name": "design", "label": "Design", "channels": ["#design"]}]}}`,
+	} {
+		_, err := mcdowell.ParseConfig(strings.NewReader(config))
+		assert.NotNil(t, err, config)
+	}
+}
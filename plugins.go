@@ -0,0 +1,187 @@
+package mcdowell
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// Event types plugins can handle.
+const (
+	EventMessage             = "message"
+	EventTeamJoin            = "team_join"
+	EventUserChange          = "user_change"
+	EventReactionAdded       = "reaction_added"
+	EventMemberJoinedChannel = "member_joined_channel"
+	EventSlashCommand        = "slash_command"
+	EventInteraction         = "interaction"
+	EventViewSubmission      = "view_submission"
+)
+
+type (
+	// Handler handles an event of the type it's registered for, e.g. a
+	// *slack.MessageEvent for EventMessage, a *slack.InteractionCallback
+	// for EventInteraction or a *ViewSubmission for EventViewSubmission.
+	Handler func(b *Bot, event interface{}) error
+
+	// Plugin is a self contained piece of bot behaviour, like a jobs board,
+	// that can live in its own package.
+	Plugin interface {
+		// Name identifies the plugin, e.g. in logs and config.
+		Name() string
+		// Init readies the plugin once the bot is connected, e.g. to register
+		// commands. The bot won't start if it fails.
+		Init(b *Bot) error
+		// Handlers returns the plugin's handlers keyed by the event type they handle.
+		Handlers() map[string]Handler
+	}
+
+	// pluginHandler is a handler along with the plugin it came from.
+	pluginHandler struct {
+		plugin  string
+		handler Handler
+	}
+)
+
+// eventType returns the type of event, or "" for events plugins can't handle.
+func eventType(event interface{}) string {
+	switch event.(type) {
+	case *slack.MessageEvent:
+		return EventMessage
+	case *slack.TeamJoinEvent:
+		return EventTeamJoin
+	case *slack.UserChangeEvent:
+		return EventUserChange
+	case *slack.ReactionAddedEvent:
+		return EventReactionAdded
+	case *slack.MemberJoinedChannelEvent:
+		return EventMemberJoinedChannel
+	case *slack.SlashCommand:
+		return EventSlashCommand
+	case *slack.InteractionCallback:
+		return EventInteraction
+	case *ViewSubmission:
+		return EventViewSubmission
+	default:
+		return ""
+	}
+}
+
+// initPlugins initializes every plugin and collects their handlers.
+func (b *Bot) initPlugins() error {
+	names := map[string]bool{}
+	b.handlers = map[string][]pluginHandler{}
+
+	for _, p := range b.plugins {
+		name := p.Name()
+
+		if name == "" || strings.ContainsAny(name, " \t\n") {
+			return errors.Errorf("invalid plugin name %q", name)
+		}
+
+		if names[name] {
+			return errors.Errorf("plugin %q is registered twice", name)
+		}
+
+		names[name] = true
+
+		if err := p.Init(b); err != nil {
+			return errors.Wrapf(err, "unable to initialize the %s plugin", name)
+		}
+
+		for event, handler := range p.Handlers() {
+			b.handlers[event] = append(b.handlers[event], pluginHandler{plugin: name, handler: handler})
+		}
+
+		log.Printf("initialized the %s plugin\n", name)
+	}
+
+	return nil
+}
+
+// runPlugins hands event to every plugin that handles its type, returning
+// the first error.
+func (b *Bot) runPlugins(event interface{}) error {
+	var firstErr error
+
+	for _, h := range b.handlers[eventType(event)] {
+		if err := h.handler(b, event); err != nil {
+			if invalid, ok := errors.Cause(err).(SubmissionErrors); ok {
+				return invalid
+			}
+
+			log.Printf("the %s plugin failed to handle %T: %v\n", h.plugin, event, err)
+
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "%s plugin", h.plugin)
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// PluginConfig decodes the plugin's section of the config file, under
+// "plugins", into v. It returns ErrNotFound if the plugin has no section.
+func (b *Bot) PluginConfig(plugin string, v interface{}) error {
+	if b.config == nil || b.config.Plugins[plugin] == nil {
+		return ErrNotFound
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b.config.Plugins[plugin]))
+	decoder.DisallowUnknownFields()
+
+	return errors.Wrapf(decoder.Decode(v), "invalid config for the %s plugin", plugin)
+}
+
+// Client returns the Slack client the bot talks to Slack with.
+func (b *Bot) Client() SlackClient {
+	return b.client
+}
+
+// Store returns where the bot keeps its state.
+func (b *Bot) Store() Store {
+	return b.store
+}
+
+// Context returns the context the bot runs under, done when it shuts down.
+func (b *Bot) Context() context.Context {
+	return b.ctx
+}
+
+// Now returns the bot's idea of the current time.
+func (b *Bot) Now() time.Time {
+	return b.now()
+}
+
+// ResolveChannel returns the ID of channel, given by ID or name.
+func (b *Bot) ResolveChannel(channel string) (string, error) {
+	if !isChannelName(channel) {
+		return channel, nil
+	}
+
+	ids, err := b.channelIDs()
+	if err != nil {
+		return "", err
+	}
+
+	id, ok := ids[normalizeChannelName(channel)]
+	if !ok {
+		return "", errors.Errorf("no channel called #%s", normalizeChannelName(channel))
+	}
+
+	return id, nil
+}
+
+// WithPlugin adds p to the bot.
+func WithPlugin(p Plugin) func(*Bot) {
+	return func(b *Bot) {
+		b.plugins = append(b.plugins, p)
+	}
+}
@@ -0,0 +1,197 @@
+package mcdowell_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+type recordingPlugin struct {
+	name    string
+	command string
+	initErr error
+	fail    error
+
+	initializedAs mcdowell.Identity
+	events        []interface{}
+}
+
+func (p *recordingPlugin) Name() string {
+	return p.name
+}
+
+func (p *recordingPlugin) Init(b *mcdowell.Bot) error {
+	if p.initErr != nil {
+		return p.initErr
+	}
+
+	p.initializedAs = b.Identity()
+
+	if p.command == "" {
+		return nil
+	}
+
+	return b.RegisterCommand(mcdowell.Command{
+		Name:        p.command,
+		Description: "Waves hello.",
+		Handler: func(b *mcdowell.Bot, req *mcdowell.CommandRequest) (string, error) {
+			return ":wave:", nil
+		},
+	})
+}
+
+func (p *recordingPlugin) Handlers() map[string]mcdowell.Handler {
+	record := func(b *mcdowell.Bot, event interface{}) error {
+		p.events = append(p.events, event)
+		return p.fail
+	}
+
+	return map[string]mcdowell.Handler{
+		mcdowell.EventMessage:        record,
+		mcdowell.EventTeamJoin:       record,
+		mcdowell.EventInteraction:    record,
+		mcdowell.EventSlashCommand:   record,
+		mcdowell.EventViewSubmission: record,
+	}
+}
+
+func TestPluginsHandleEvents(t *testing.T) {
+	plugin := &recordingPlugin{name: "recorder", command: "wave"}
+
+	m, _, _ := slacktest.NewBot(t, "", nil, mcdowell.WithSigningSecret(testSigningSecret), mcdowell.WithPlugin(plugin))
+
+	assert.Equal(t, "U0MCDOWELL", plugin.initializedAs.UserID)
+
+	reply, err := m.RunCommand(&mcdowell.CommandRequest{Command: "wave"})
+	assert.Nil(t, err)
+	assert.Equal(t, ":wave:", reply)
+
+	message := &slack.MessageEvent{Msg: slack.Msg{Channel: "C0000GENERAL", User: "U0AKEEM", Text: "hello"}}
+	join := &slack.TeamJoinEvent{User: slack.User{ID: "U0SEMMI", Name: "semmi"}}
+	change := &slack.UserChangeEvent{User: slack.User{ID: "U0SEMMI", Name: "semmi"}}
+
+	for _, event := range []interface{}{message, join, change} {
+		assert.Nil(t, m.HandleEvent(event))
+	}
+
+	assert.Equal(t, []interface{}{message, join}, plugin.events)
+}
+
+func TestPluginErrorsAreReported(t *testing.T) {
+	m, _, _ := slacktest.NewBot(t, "", nil, mcdowell.WithPlugin(&recordingPlugin{name: "broken", fail: errors.New("boom")}), mcdowell.WithPlugin(&recordingPlugin{name: "working"}))
+
+	err := m.HandleEvent(&slack.MessageEvent{Msg: slack.Msg{Channel: "C0000GENERAL", User: "U0AKEEM", Text: "hello"}})
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "broken plugin: boom")
+}
+
+func TestBadPluginsKeepTheBotFromStarting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := func(plugins ...mcdowell.Plugin) error {
+		options := []func(*mcdowell.Bot){mcdowell.WithTesting()}
+		for _, p := range plugins {
+			options = append(options, mcdowell.WithPlugin(p))
+		}
+
+		_, err := mcdowell.NewBot(ctx, slacktest.NewServer(t, nil).Client(), options...)
+
+		return err
+	}
+
+	assert.NotNil(t, start(&recordingPlugin{name: "failing", initErr: errors.New("boom")}))
+	assert.NotNil(t, start(&recordingPlugin{name: "twin"}, &recordingPlugin{name: "twin"}))
+	assert.NotNil(t, start(&recordingPlugin{name: "no spaces"}))
+}
+
+func TestPluginConfig(t *testing.T) {
+	m, _, _ := slacktest.NewBot(t, `{"plugins": {"recorder": {"channel": "#recordings"}}}`, nil)
+
+	var config struct {
+		Channel string `json:"channel"`
+	}
+
+	assert.Nil(t, m.PluginConfig("recorder", &config))
+	assert.Equal(t, "#recordings", config.Channel)
+
+	assert.Equal(t, mcdowell.ErrNotFound, m.PluginConfig("missing", &config))
+
+	var strict struct {
+		Room string `json:"room"`
+	}
+
+	assert.NotNil(t, m.PluginConfig("recorder", &strict))
+}
+
+// jobSubmission is a modal submission with a bad link.
+const jobSubmission = `{
+	"type": "view_submission",
+	"user": {"id": "U0AKEEM"},
+	"view": {
+		"callback_id": "jobs:post",
+		"state": {"values": {
+			"link": {"link": {"type": "plain_text_input", "value": "nope"}},
+			"workplace": {"workplace": {"type": "static_select", "selected_option": {"value": "remote"}}}
+		}}
+	}
+}`
+
+func TestPluginsCanRejectViewSubmissions(t *testing.T) {
+	p := &recordingPlugin{name: "jobs"}
+
+	m, _, _ := slacktest.NewBot(t, "", nil, mcdowell.WithSigningSecret(testSigningSecret), mcdowell.WithPlugin(p))
+
+	submit := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		m.HandleInteraction(w, interaction(t, jobSubmission))
+
+		return w
+	}
+
+	p.fail = mcdowell.SubmissionErrors{"link": "Enter a link.", "company": "Who's hiring?"}
+
+	w := submit()
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"response_action": "errors", "errors": {"company": "Who's hiring?", "link": "Enter a link."}}`, w.Body.String())
+
+	// Wrapped with a stack trace, they're still shown next to the fields.
+	p.fail = errors.WithStack(mcdowell.SubmissionErrors{"link": "Enter a link."})
+
+	w = submit()
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"response_action": "errors", "errors": {"link": "Enter a link."}}`, w.Body.String())
+
+	var submission mcdowell.ViewSubmission
+	assert.Nil(t, json.Unmarshal([]byte(jobSubmission), &submission))
+	assert.Equal(t, mcdowell.SubmissionErrors{"link": "Enter a link."}, m.HandleEvent(&submission))
+
+	p.fail = nil
+
+	w = submit()
+	assert.Equal(t, 200, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	assert.Len(t, p.events, 4)
+	assert.Equal(t, map[string]string{"link": "nope", "workplace": "remote"}, p.events[3].(*mcdowell.ViewSubmission).Values())
+}
+
+func TestPluginsHandleSlashCommandsOverHTTP(t *testing.T) {
+	p := &recordingPlugin{name: "recorder"}
+
+	m, _, _ := slacktest.NewBot(t, "", nil, mcdowell.WithSigningSecret(testSigningSecret), mcdowell.WithPlugin(p))
+
+	w := httptest.NewRecorder()
+	m.HandleSlashCommand(w, slashCommand(t, testSigningSecret, "help"))
+	assert.Contains(t, slashReply(t, w), "Here's what I can do:")
+
+	assert.Len(t, p.events, 1)
+	assert.Equal(t, "help", p.events[0].(*slack.SlashCommand).Text)
+}
@@ -0,0 +1,220 @@
+package mcdowell
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// Roles members of the roster can have.
+const (
+	RoleAdmin      = "admin"
+	RoleMaintainer = "maintainer"
+)
+
+var (
+	userIDPattern      = regexp.MustCompile(`^[UW][A-Z0-9]{6,}$`)
+	userGroupIDPattern = regexp.MustCompile(`^S[A-Z0-9]{6,}$`)
+)
+
+type (
+	// RosterConfig declares who looks after the bot. Admins can run
+	// privileged commands; admins and maintainers are told about deployments,
+	// unless there's an OpsChannel to post them in. People can be given by
+	// username or ID and groups by handle or ID.
+	RosterConfig struct {
+		Admins          []string `json:"admins,omitempty"`
+		Maintainers     []string `json:"maintainers,omitempty"`
+		AdminGroup      string   `json:"admin_group,omitempty"`
+		MaintainerGroup string   `json:"maintainer_group,omitempty"`
+		OpsChannel      string   `json:"ops_channel,omitempty"`
+	}
+
+	// roster maps the IDs of the people who look after the bot to their role.
+	roster map[string]string
+)
+
+func (c *RosterConfig) validate() error {
+	if len(c.Admins) == 0 && c.AdminGroup == "" {
+		return errors.New("no admins")
+	}
+
+	return nil
+}
+
+// rosterConfig returns the configured roster, which is empty if there isn't one.
+func (b *Bot) rosterConfig() *RosterConfig {
+	if b.config == nil || b.config.Roster == nil {
+		return &RosterConfig{}
+	}
+
+	return b.config.Roster
+}
+
+// resolveRoster looks up the IDs of everyone on the roster. Admins who are
+// also listed as maintainers stay admins.
+func (b *Bot) resolveRoster() error {
+	if b.config == nil || b.config.Roster == nil {
+		log.Println("WARNING: there's no roster in the config, so nobody can run privileged commands or is told about deployments")
+		return nil
+	}
+
+	config := b.config.Roster
+
+	r := roster{}
+
+	maintainers, err := b.resolveMembers(config.Maintainers, config.MaintainerGroup)
+	if err != nil {
+		return errors.Wrap(err, "unable to resolve maintainers")
+	}
+
+	for _, id := range maintainers {
+		r[id] = RoleMaintainer
+	}
+
+	admins, err := b.resolveMembers(config.Admins, config.AdminGroup)
+	if err != nil {
+		return errors.Wrap(err, "unable to resolve admins")
+	}
+
+	for _, id := range admins {
+		r[id] = RoleAdmin
+	}
+
+	if len(admins) == 0 {
+		log.Println("none of the bot's admins could be found, privileged commands are unavailable")
+	}
+
+	b.rosterMu.Lock()
+	b.roster = r
+	b.rosterMu.Unlock()
+
+	if b.Debug {
+		log.Println("roster:", r)
+	}
+
+	return nil
+}
+
+// resolveMembers returns the IDs of people, given by username or ID, and
+// the members of group.
+func (b *Bot) resolveMembers(people []string, group string) ([]string, error) {
+	var members []string
+
+	for _, person := range people {
+		person = strings.TrimPrefix(person, "@")
+
+		if userIDPattern.MatchString(person) {
+			members = append(members, person)
+		} else if m, ok := b.directory.ByName(person); ok {
+			members = append(members, m.ID)
+		} else {
+			log.Printf("unable to find @%s, leaving them off the roster\n", person)
+		}
+	}
+
+	if group == "" {
+		return members, nil
+	}
+
+	groupMembers, err := b.userGroupMembers(group)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(members, groupMembers...), nil
+}
+
+// userGroupMembers returns the IDs of everyone in group, given by handle or ID.
+func (b *Bot) userGroupMembers(group string) ([]string, error) {
+	group = strings.TrimPrefix(group, "@")
+
+	if userGroupIDPattern.MatchString(group) {
+		members, err := b.client.GetUserGroupMembers(group)
+		return members, errors.WithStack(err)
+	}
+
+	groups, err := b.client.GetUserGroups(slack.GetUserGroupsOptionIncludeUsers(true))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	for _, g := range groups {
+		if g.Handle == group {
+			return g.Users, nil
+		}
+	}
+
+	return nil, errors.Errorf("no user group called @%s", group)
+}
+
+// role returns userID's role on the roster, or "" if they aren't on it.
+func (b *Bot) role(userID string) string {
+	b.rosterMu.RLock()
+	defer b.rosterMu.RUnlock()
+
+	return b.roster[userID]
+}
+
+// IsAdmin reports whether userID is one of the bot's admins.
+func (b *Bot) IsAdmin(userID string) bool {
+	return b.role(userID) == RoleAdmin
+}
+
+// notifyRoster lets everyone on the roster know about message, or posts it
+// in the ops channel if there is one.
+func (b *Bot) notifyRoster(message string) {
+	var recipients []string
+
+	if b.opsChannel != "" {
+		recipients = []string{b.opsChannel}
+	} else {
+		b.rosterMu.RLock()
+		for id := range b.roster {
+			recipients = append(recipients, id)
+		}
+		b.rosterMu.RUnlock()
+
+		sort.Strings(recipients)
+	}
+
+	for _, recipient := range recipients {
+		_, _, err := b.client.PostMessage(recipient,
+			slack.MsgOptionAsUser(true),
+			slack.MsgOptionText(message, false),
+		)
+		if err != nil {
+			log.Printf("failed to notify %s: %v\n", recipient, err)
+		}
+	}
+}
+
+// resolveOpsChannel looks up the ID of the ops channel.
+func (b *Bot) resolveOpsChannel(ids map[string]string) error {
+	channel := b.rosterConfig().OpsChannel
+	if channel == "" {
+		return nil
+	}
+
+	id, ok := resolveChannel(channel, ids)
+	if !ok {
+		return errors.Errorf("unable to resolve the ops channel %s", channel)
+	}
+
+	b.opsChannel = id
+
+	return nil
+}
+
+func reload(b *Bot, req *CommandRequest) (string, error) {
+	if err := b.ReloadTriggers(); err != nil {
+		return fmt.Sprintf("Sorry, the trigger catalog is broken so I kept the old one: %v", err), nil
+	}
+
+	return "Reloaded the trigger catalog.", nil
+}
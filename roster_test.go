@@ -0,0 +1,112 @@
+package mcdowell_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+const rosterUsers = `{
+	"ok": true,
+	"members": [
+		{"id": "U0WILL", "name": "willmadison"},
+		{"id": "U0XANGO", "name": "xango"},
+		{"id": "U0CLEO", "name": "cleo"},
+		{"id": "U0SEMMI", "name": "semmi"}
+	]
+}`
+
+// rosterResponses are the members, groups and channels the roster is made of.
+var rosterResponses = map[string]string{
+	"users.list":         rosterUsers,
+	"usergroups.list":    `{"ok": true, "usergroups": [{"id": "S0ORGANIZERS", "handle": "organizers", "users": ["U0LISA"]}]}`,
+	"conversations.list": `{"ok": true, "channels": [{"id": "C0000000OPS", "name": "ops"}]}`,
+}
+
+// deployNotices returns who was told about the deployment.
+func deployNotices(fake *slacktest.Server) []string {
+	var recipients []string
+
+	for _, call := range fake.Calls("chat.postMessage") {
+		if strings.HasPrefix(call.Form.Get("text"), "sucessfully deployed") {
+			recipients = append(recipients, call.Form.Get("channel"))
+		}
+	}
+
+	return recipients
+}
+
+func TestMissingRoster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := slacktest.NewServer(t, rosterResponses)
+
+	m, err := mcdowell.NewBot(ctx, fake.Client(), mcdowell.WithTesting(), mcdowell.Versioned("1.0.0"))
+	assert.Nil(t, err)
+
+	assert.Empty(t, deployNotices(fake))
+
+	assert.False(t, m.IsAdmin("U0WILL"))
+	assert.False(t, m.IsAdmin("U0XANGO"))
+}
+
+func TestConfiguredRoster(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := slacktest.NewServer(t, rosterResponses)
+
+	m, err := mcdowell.NewBot(ctx, fake.Client(), mcdowell.WithTesting(), mcdowell.Versioned("1.0.0"), slacktest.WithConfig(t, `{
+		"roster": {
+			"admins": ["cleo"],
+			"admin_group": "@organizers",
+			"maintainers": ["U0SEMMI", "cleo", "nobody"]
+		}
+	}`))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"U0CLEO", "U0LISA", "U0SEMMI"}, deployNotices(fake))
+
+	assert.True(t, m.IsAdmin("U0CLEO"))
+	assert.True(t, m.IsAdmin("U0LISA"))
+	assert.False(t, m.IsAdmin("U0SEMMI"))
+	assert.False(t, m.IsAdmin("U0WILL"))
+}
+
+func TestDeployNoticesGoToTheOpsChannel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fake := slacktest.NewServer(t, rosterResponses)
+
+	_, err := mcdowell.NewBot(ctx, fake.Client(), mcdowell.WithTesting(), mcdowell.Versioned("1.0.0"), slacktest.WithConfig(t, `{"roster": {"admins": ["cleo"], "ops_channel": "#ops"}}`))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"C0000000OPS"}, deployNotices(fake))
+}
+
+func TestAdminOnlyCommands(t *testing.T) {
+	m, _, _ := slacktest.NewBot(t, `{"roster": {"admins": ["cleo"]}}`, rosterResponses)
+
+	reply, err := m.RunCommand(&mcdowell.CommandRequest{Command: "reload", UserID: "U0SEMMI"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Sorry, only admins can `reload`.", reply)
+
+	reply, err = m.RunCommand(&mcdowell.CommandRequest{Command: "reload", UserID: "U0CLEO"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Reloaded the trigger catalog.", reply)
+
+	reply, err = m.RunCommand(&mcdowell.CommandRequest{Command: "help", UserID: "U0SEMMI"})
+	assert.Nil(t, err)
+	assert.Contains(t, reply, "• `reload` - Reloads the trigger catalog. (admins only)")
+}
+
+func TestRosterWithoutAdminsIsRejected(t *testing.T) {
+	_, err := mcdowell.ParseConfig(strings.NewReader(`{"roster": {"maintainers": ["cleo"]}}`))
+	assert.NotNil(t, err)
+}
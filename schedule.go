@@ -0,0 +1,225 @@
+package mcdowell
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// scheduleDescriptors are the shorthands ParseSchedule accepts for common schedules.
+var scheduleDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// scheduleField describes one of the five fields of a cron expression.
+type scheduleField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var scheduleFields = []scheduleField{
+	{name: "minute", max: 59},
+	{name: "hour", max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// scheduleHorizon is how far ahead Next looks before giving up on a schedule.
+const scheduleHorizon = 5 * 366 * 24 * time.Hour
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expression string
+
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+
+	// anyDayOfMonth and anyDayOfWeek record a "*" day field. As with cron,
+	// when both day fields are restricted either one matching is enough.
+	anyDayOfMonth, anyDayOfWeek bool
+
+	// anyHour records a "*" hour field. Those schedules run through the hour
+	// repeated when clocks go back as usual.
+	anyHour bool
+}
+
+// ParseSchedule parses a standard five field cron expression ("minute hour
+// day-of-month month day-of-week"), e.g. "0 12 * * WED" for noon every
+// Wednesday. Fields can be "*", numbers, names (JAN-DEC, SUN-SAT), ranges,
+// lists and steps like "*/15" or "1-5/2". The @yearly, @monthly, @weekly,
+// @daily and @hourly shorthands work too.
+func ParseSchedule(expression string) (Schedule, error) {
+	s := Schedule{expression: expression}
+
+	spec := strings.TrimSpace(expression)
+	if descriptor, ok := scheduleDescriptors[strings.ToLower(spec)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(scheduleFields) {
+		return s, errors.Errorf("invalid schedule %q: want 5 fields, got %d", expression, len(fields))
+	}
+
+	bits := []*uint64{&s.minute, &s.hour, &s.dayOfMonth, &s.month, &s.dayOfWeek}
+
+	for i, field := range scheduleFields {
+		var err error
+
+		*bits[i], err = field.parse(fields[i])
+		if err != nil {
+			return s, errors.Wrapf(err, "invalid schedule %q", expression)
+		}
+	}
+
+	// Sunday is both 0 and 7.
+	if s.dayOfWeek&(1<<7) != 0 {
+		s.dayOfWeek = s.dayOfWeek&^(1<<7) | 1
+	}
+
+	s.anyHour = fields[1] == "*"
+	s.anyDayOfMonth = fields[2] == "*"
+	s.anyDayOfWeek = fields[4] == "*"
+
+	if s.Next(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return s, errors.Errorf("invalid schedule %q: it never runs", expression)
+	}
+
+	return s, nil
+}
+
+// parse returns the values matched by value as a bitset.
+func (f scheduleField) parse(value string) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+
+			rangePart = part[:i]
+
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, errors.Errorf("invalid step in %s %q", f.name, part)
+			}
+		}
+
+		low, high := f.min, f.max
+
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+
+			var err error
+
+			low, err = f.value(bounds[0])
+			if err != nil {
+				return 0, err
+			}
+
+			high = low
+			if len(bounds) == 2 {
+				high, err = f.value(bounds[1])
+				if err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				high = f.max
+			}
+
+			if high < low {
+				return 0, errors.Errorf("invalid range in %s %q", f.name, part)
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// value parses a single number or name in the field.
+func (f scheduleField) value(value string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(value, name) {
+			return i, nil
+		}
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < f.min || n > f.max {
+		return 0, errors.Errorf("invalid %s %q", f.name, value)
+	}
+
+	return n, nil
+}
+
+// String returns the expression s was parsed from.
+func (s Schedule) String() string {
+	return s.expression
+}
+
+// Next returns the first time after t the schedule matches, in t's time
+// zone, or the zero time if it doesn't match in the next five years. When
+// clocks go back, times in the repeated hour that were already reached before
+// the change are skipped unless the schedule runs every hour, so a job at
+// 01:30 runs once that night rather than twice.
+func (s Schedule) Next(t time.Time) time.Time {
+	limit := t.Add(scheduleHorizon)
+	location := t.Location()
+	reached := wallClock(t)
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		year, month, day := t.Date()
+
+		switch {
+		case s.month&(1<<uint(month)) == 0:
+			t = time.Date(year, month+1, 1, 0, 0, 0, 0, location)
+		case !s.matchesDay(t):
+			t = time.Date(year, month, day+1, 0, 0, 0, 0, location)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, location)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		case !s.anyHour && !wallClock(t).After(reached):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// wallClock returns the date and time t shows on a clock in its time zone,
+// which goes back over the same hour when daylight saving time ends.
+func wallClock(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func (s Schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+
+	if s.anyDayOfMonth || s.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+
+	return dayOfMonth || dayOfWeek
+}
@@ -0,0 +1,67 @@
+package mcdowell_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+)
+
+func TestSchedules(t *testing.T) {
+	eastern, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	// A Tuesday.
+	from := time.Date(2019, time.June, 25, 12, 30, 0, 0, eastern)
+
+	cases := []struct {
+		expression string
+		next       time.Time
+	}{
+		{"0 12 * * WED", time.Date(2019, time.June, 26, 12, 0, 0, 0, eastern)},
+		{"0 10 1 * *", time.Date(2019, time.July, 1, 10, 0, 0, 0, eastern)},
+		{"*/15 * * * *", time.Date(2019, time.June, 25, 12, 45, 0, 0, eastern)},
+		{"30 9-17/4 * * mon-fri", time.Date(2019, time.June, 25, 13, 30, 0, 0, eastern)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, eastern)},
+		{"0 0 1 * 7", time.Date(2019, time.June, 30, 0, 0, 0, 0, eastern)},
+		{"0 0 1,15 jan,jul *", time.Date(2019, time.July, 1, 0, 0, 0, 0, eastern)},
+		{"@weekly", time.Date(2019, time.June, 30, 0, 0, 0, 0, eastern)},
+		{"@monthly", time.Date(2019, time.July, 1, 0, 0, 0, 0, eastern)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expression, func(t *testing.T) {
+			s, err := mcdowell.ParseSchedule(c.expression)
+			assert.Nil(t, err)
+			assert.Equal(t, c.next, s.Next(from))
+			assert.Equal(t, c.expression, s.String())
+		})
+	}
+}
+
+func TestSchedulesRunOnceWhenClocksGoBack(t *testing.T) {
+	eastern, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	// 01:30 EDT on November 3rd 2019, an hour before 01:30 EST.
+	firstRun := time.Date(2019, time.November, 3, 5, 30, 0, 0, time.UTC).In(eastern)
+
+	daily, err := mcdowell.ParseSchedule("30 1 * * *")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2019, time.November, 4, 1, 30, 0, 0, eastern), daily.Next(firstRun))
+
+	// Nor from later in the first 01:00 hour, e.g. after a restart.
+	assert.Equal(t, time.Date(2019, time.November, 4, 1, 30, 0, 0, eastern), daily.Next(firstRun.Add(29*time.Minute)))
+
+	hourly, err := mcdowell.ParseSchedule("30 * * * *")
+	assert.Nil(t, err)
+	assert.Equal(t, firstRun.Add(time.Hour), hourly.Next(firstRun))
+}
+
+func TestInvalidSchedules(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "0 12 * * FUNDAY", "0 0 31 2 *", "*/0 * * * *", "5-1 * * * *"} {
+		_, err := mcdowell.ParseSchedule(expression)
+		assert.NotNil(t, err, expression)
+	}
+}
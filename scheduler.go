@@ -0,0 +1,177 @@
+package mcdowell
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTimeZone is where job schedules are read unless configured otherwise.
+const DefaultTimeZone = "America/New_York"
+
+// jobsBucket is where each job's last run is stored, keyed by job name.
+const jobsBucket = "jobs"
+
+// schedulerInterval is how often the scheduler checks for jobs that are due.
+const schedulerInterval = time.Minute
+
+type (
+	// JobFunc runs a scheduled job. last is when the job last ran, or when
+	// it was first scheduled if it has never run.
+	JobFunc func(b *Bot, last time.Time) error
+
+	// Job is something the bot does on a schedule, e.g. post a prompt every
+	// Wednesday. Schedule is a cron expression (see ParseSchedule) read in
+	// the bot's time zone.
+	Job struct {
+		Name     string
+		Schedule string
+		Run      JobFunc
+	}
+
+	scheduledJob struct {
+		Job
+		schedule Schedule
+	}
+)
+
+// RegisterJob schedules j. When each job last ran is kept in the bot's store
+// so a restart neither repeats nor skips a run: a job that came due while the
+// bot was down runs once as soon as it's back.
+func (b *Bot) RegisterJob(j Job) error {
+	if j.Name == "" {
+		return errors.New("job has no name")
+	}
+
+	if j.Run == nil {
+		return errors.Errorf("job %q has nothing to run", j.Name)
+	}
+
+	schedule, err := ParseSchedule(j.Schedule)
+	if err != nil {
+		return errors.Wrapf(err, "job %q", j.Name)
+	}
+
+	b.jobsMu.Lock()
+	defer b.jobsMu.Unlock()
+
+	if b.jobs == nil {
+		b.jobs = map[string]scheduledJob{}
+	}
+
+	if _, ok := b.jobs[j.Name]; ok {
+		return errors.Errorf("job %q is already registered", j.Name)
+	}
+
+	var last time.Time
+
+	err = getJSON(b.store, jobsBucket, j.Name, &last)
+	if err == ErrNotFound {
+		err = putJSON(b.store, jobsBucket, j.Name, b.now())
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to schedule job %q", j.Name)
+	}
+
+	b.jobs[j.Name] = scheduledJob{Job: j, schedule: schedule}
+
+	return nil
+}
+
+// Jobs returns every scheduled job, sorted by name.
+func (b *Bot) Jobs() []Job {
+	b.jobsMu.Lock()
+	defer b.jobsMu.Unlock()
+
+	jobs := make([]Job, 0, len(b.jobs))
+	for _, j := range b.jobs {
+		jobs = append(jobs, j.Job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Name < jobs[j].Name
+	})
+
+	return jobs
+}
+
+// RunDueJobs runs every job whose next run, counting from its last, has come.
+// A job's run is recorded before it starts, so one that fails isn't retried
+// until it's next due.
+func (b *Bot) RunDueJobs() error {
+	b.schedulerMu.Lock()
+	defer b.schedulerMu.Unlock()
+
+	b.jobsMu.Lock()
+	jobs := make([]scheduledJob, 0, len(b.jobs))
+	for _, j := range b.jobs {
+		jobs = append(jobs, j)
+	}
+	b.jobsMu.Unlock()
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Name < jobs[j].Name
+	})
+
+	now := b.now()
+
+	var err error
+
+	for _, j := range jobs {
+		name := j.Name
+
+		var last time.Time
+
+		if getErr := getJSON(b.store, jobsBucket, name, &last); getErr != nil && getErr != ErrNotFound {
+			log.Printf("unable to tell when job %q last ran: %v\n", name, getErr)
+			continue
+		}
+
+		next := j.schedule.Next(last.In(b.location))
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+
+		if putErr := putJSON(b.store, jobsBucket, name, now); putErr != nil {
+			log.Printf("unable to record job %q running: %v\n", name, putErr)
+			continue
+		}
+
+		if b.Debug || b.Testing {
+			log.Printf("running job %q, due at %s\n", name, next)
+		}
+
+		if runErr := j.Run(b, last); runErr != nil {
+			log.Printf("job %q failed: %v\n", name, runErr)
+
+			if err == nil {
+				err = errors.Wrapf(runErr, "job %q", name)
+			}
+		}
+	}
+
+	return err
+}
+
+// runScheduler runs jobs as they come due until the bot shuts down.
+func (b *Bot) runScheduler() {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+			b.RunDueJobs()
+		}
+	}
+}
+
+// Location returns the time zone the bot's schedules are read in.
+func (b *Bot) Location() *time.Location {
+	return b.location
+}
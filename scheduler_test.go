@@ -0,0 +1,166 @@
+package mcdowell_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+// A Tuesday morning in Atlanta.
+var tuesday = time.Date(2019, time.June, 25, 14, 0, 0, 0, time.UTC)
+
+// scheduledResponses are who posts wins and where.
+var scheduledResponses = map[string]string{
+	"users.list":         `{"ok": true, "members": [{"id": "U0AKEEM", "name": "akeem", "profile": {"display_name": "Prince Akeem"}}]}`,
+	"conversations.list": `{"ok": true, "channels": [{"id": "C000000WINS", "name": "wins"}, {"id": "C0000GENERAL", "name": "general"}]}`,
+}
+
+// postedIn returns the text of every message posted in channel.
+func postedIn(fake *slacktest.Server, channel string) []string {
+	var texts []string
+	for _, call := range fake.Calls("chat.postMessage") {
+		if call.Form.Get("channel") == channel {
+			texts = append(texts, call.Form.Get("text"))
+		}
+	}
+
+	return texts
+}
+
+func TestJobsRunWhenDue(t *testing.T) {
+	clock := slacktest.NewClock(tuesday)
+	m, _, _ := slacktest.NewBot(t, "", scheduledResponses, mcdowell.WithClock(clock.Now))
+
+	var runs []time.Time
+
+	err := m.RegisterJob(mcdowell.Job{Name: "hourly", Schedule: "@hourly", Run: func(b *mcdowell.Bot, last time.Time) error {
+		runs = append(runs, last)
+		return nil
+	}})
+	assert.Nil(t, err)
+
+	assert.NotNil(t, m.RegisterJob(mcdowell.Job{Name: "hourly", Schedule: "@daily", Run: func(*mcdowell.Bot, time.Time) error { return nil }}))
+	assert.NotNil(t, m.RegisterJob(mcdowell.Job{Name: "never", Schedule: "0 0 31 2 *", Run: func(*mcdowell.Bot, time.Time) error { return nil }}))
+	assert.NotNil(t, m.RegisterJob(mcdowell.Job{Name: "nothing", Schedule: "@daily"}))
+
+	assert.Nil(t, m.RunDueJobs())
+	assert.Empty(t, runs)
+
+	clock.Set(tuesday.Add(59 * time.Minute))
+	assert.Nil(t, m.RunDueJobs())
+	assert.Empty(t, runs)
+
+	clock.Set(tuesday.Add(time.Hour))
+	assert.Nil(t, m.RunDueJobs())
+	assert.Nil(t, m.RunDueJobs())
+	assert.Equal(t, []time.Time{tuesday}, runs)
+
+	// Missed runs are caught up on once, not once per missed hour.
+	clock.Set(tuesday.Add(5 * time.Hour))
+	assert.Nil(t, m.RunDueJobs())
+	assert.Equal(t, []time.Time{tuesday, tuesday.Add(time.Hour)}, runs)
+}
+
+func TestJobsSurviveRestarts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := mcdowell.NewMemoryStore()
+	clock := slacktest.NewClock(tuesday)
+	config := slacktest.WithConfig(t, `{"wins": {"channel": "#wins"}}`)
+
+	// deploy starts the bot again, returning it and what it posted starting up.
+	deploy := func() (*mcdowell.Bot, *slacktest.Server) {
+		fake := slacktest.NewServer(t, scheduledResponses)
+
+		m, err := mcdowell.NewBot(ctx, fake.Client(), mcdowell.WithTesting(), config, mcdowell.WithStore(store), mcdowell.WithClock(clock.Now))
+		assert.Nil(t, err)
+
+		return m, fake
+	}
+
+	_, fake := deploy()
+	assert.Empty(t, postedIn(fake, "C000000WINS"))
+
+	// Down over Wednesday noon, the prompt goes out once on the way back up.
+	clock.Set(tuesday.Add(30 * time.Hour))
+	_, fake = deploy()
+	assert.Equal(t, []string{mcdowell.DefaultWinsPrompt}, postedIn(fake, "C000000WINS"))
+
+	// And not again after another redeploy.
+	clock.Set(tuesday.Add(31 * time.Hour))
+	m, fake := deploy()
+	assert.Nil(t, m.RunDueJobs())
+	assert.Empty(t, postedIn(fake, "C000000WINS"))
+}
+
+func TestWinsWednesday(t *testing.T) {
+	clock := slacktest.NewClock(tuesday)
+	m, fake, _ := slacktest.NewBot(t, `{"wins": {"channel": "#wins"}, "newsletter": {"channel": "#general"}}`, scheduledResponses, mcdowell.WithClock(clock.Now))
+
+	var names []string
+	for _, j := range m.Jobs() {
+		names = append(names, j.Name+" "+j.Schedule)
+	}
+
+	assert.Equal(t, []string{"newsletter 0 10 1 * *", "wins 0 12 * * WED"}, names)
+
+	// Noon on Wednesday in Atlanta.
+	clock.Set(time.Date(2019, time.June, 26, 16, 0, 0, 0, time.UTC))
+	assert.Nil(t, m.RunDueJobs())
+
+	assert.Equal(t, []string{"chat.postMessage"}, fake.Methods())
+	assert.Equal(t, "C000000WINS", fake.Last().Form.Get("channel"))
+	assert.Equal(t, mcdowell.DefaultWinsPrompt, fake.Last().Form.Get("text"))
+
+	reply := func(channel, thread, ts, text string) {
+		err := m.OnNewMessage(&slack.MessageEvent{Msg: slack.Msg{Channel: channel, User: "U0AKEEM", Text: text, ThreadTimestamp: thread, Timestamp: ts}})
+		assert.Nil(t, err)
+	}
+
+	reply("C000000WINS", "123.456", "200.000", "Got the job at McDowell's!")
+	reply("C000000WINS", "", "201.000", "Not in the thread")
+	reply("C000000WINS", "199.000", "202.000", "In some other thread")
+	reply("C0000GENERAL", "123.456", "203.000", "In another channel")
+	reply("C000000WINS", "123.456", "204.000", "Shipped my first   open source\npatch. "+strings.Repeat("So proud! ", 40))
+
+	fake.Reset()
+
+	clock.Set(time.Date(2019, time.July, 1, 14, 0, 0, 0, time.UTC))
+	assert.Nil(t, m.RunDueJobs())
+
+	assert.Equal(t, []string{"chat.postMessage"}, fake.Methods())
+	assert.Equal(t, "C0000GENERAL", fake.Last().Form.Get("channel"))
+
+	newsletter := fake.Last().Form.Get("text")
+	assert.True(t, strings.HasPrefix(newsletter, ":newspaper: *What’s been happening in ATL Black Tech*\n\n*Wins*\n• Prince Akeem: Got the job at McDowell's!\n• Prince Akeem: Shipped my first open source patch. So proud!"), newsletter)
+	assert.True(t, strings.HasSuffix(newsletter, "…"), newsletter)
+	assert.NotContains(t, newsletter, "thread")
+	assert.NotContains(t, newsletter, "another channel")
+
+	// Wins already in a newsletter aren't repeated, and quiet months don't get one.
+	fake.Reset()
+
+	clock.Set(time.Date(2019, time.August, 1, 14, 0, 0, 0, time.UTC))
+	assert.Nil(t, m.RunDueJobs())
+
+	assert.Equal(t, []string{"chat.postMessage"}, fake.Methods())
+	assert.Equal(t, "C000000WINS", fake.Last().Form.Get("channel"))
+}
+
+func TestInvalidSchedulesAreRejected(t *testing.T) {
+	for _, config := range []string{
+		`{"wins": {"channel": "#wins", "schedule": "whenever"}}`,
+		`{"newsletter": {}}`,
+		`{"time_zone": "America/Wakanda"}`,
+	} {
+		_, err := mcdowell.ParseConfig(strings.NewReader(config))
+		assert.NotNil(t, err, config)
+	}
+}
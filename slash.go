@@ -0,0 +1,141 @@
+package mcdowell
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// maxRequestBodySize caps how much of a request from Slack is read.
+const maxRequestBodySize = 1 << 20
+
+// verifyRequest reads r's body and checks it was signed by Slack with
+// signingSecret. The body is left in place for further parsing.
+func verifyRequest(r *http.Request, signingSecret string) ([]byte, error) {
+	if signingSecret == "" {
+		return nil, errors.New("no signing secret configured")
+	}
+
+	verifier, err := slack.NewSecretsVerifier(r.Header, signingSecret)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if _, err := verifier.Write(body); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := verifier.Ensure(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return body, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("failed to write response:", err)
+	}
+}
+
+// HandleSlashCommand serves slash command requests from Slack, e.g.
+// "/mcdowell help", replying privately to whoever ran the command. Plugins
+// handling slash commands get them too, as they do over Socket Mode.
+func (b *Bot) HandleSlashCommand(w http.ResponseWriter, r *http.Request) {
+	if _, err := verifyRequest(r, b.signingSecret); err != nil {
+		log.Println("rejecting slash command:", err)
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	s, err := slack.SlashCommandParse(r)
+	if err != nil {
+		http.Error(w, "invalid slash command", http.StatusBadRequest)
+		return
+	}
+
+	if reply := b.runSlashCommand(s); reply != "" {
+		writeJSON(w, slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: reply})
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	b.dispatchTo(b.runPlugins, &s)
+}
+
+// runSlashCommand runs the command s asks for and returns the text to reply with.
+func (b *Bot) runSlashCommand(s slack.SlashCommand) string {
+	command, args := ParseCommand(s.Text)
+
+	reply, err := b.RunCommand(&CommandRequest{
+		Command:   command,
+		Args:      args,
+		UserID:    s.UserID,
+		UserName:  s.UserName,
+		ChannelID: s.ChannelID,
+		TriggerID: s.TriggerID,
+	})
+	if err != nil {
+		log.Printf("%s %s failed: %v\n", s.Command, s.Text, err)
+		return "Sorry, something went wrong. Please try again later."
+	}
+
+	return reply
+}
+
+// onSlashCommand replies privately to slash commands delivered as events
+// rather than asked over Socket Mode, using the command's response URL so it
+// works in channels the bot isn't in.
+func (b *Bot) onSlashCommand(s *slack.SlashCommand) error {
+	reply := b.runSlashCommand(*s)
+	if reply == "" {
+		return nil
+	}
+
+	return respond(http.DefaultClient, s.ResponseURL, slack.Msg{ResponseType: slack.ResponseTypeEphemeral, Text: reply})
+}
+
+// respond posts msg to a slash command's response URL.
+func respond(client *http.Client, responseURL string, msg slack.Msg) error {
+	if responseURL == "" {
+		return errors.New("no response URL to reply to")
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	resp, err := client.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unable to reply to %s: %s", responseURL, resp.Status)
+	}
+
+	return nil
+}
+
+// WithSigningSecret sets the secret used to verify requests Slack sends to the bot.
+func WithSigningSecret(secret string) func(*Bot) {
+	return func(b *Bot) {
+		b.signingSecret = secret
+	}
+}
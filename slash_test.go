@@ -0,0 +1,148 @@
+package mcdowell_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// signedRequest returns a request carrying body signed the way Slack signs them.
+func signedRequest(t *testing.T, target, secret, contentType, body string) *http.Request {
+	t.Helper()
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+
+	return r
+}
+
+func slashCommand(t *testing.T, secret, text string) *http.Request {
+	t.Helper()
+
+	form := url.Values{
+		"command":    {"/mcdowell"},
+		"text":       {text},
+		"user_id":    {"U0AKEEM"},
+		"user_name":  {"akeem"},
+		"channel_id": {"C0000GENERAL"},
+		"trigger_id": {"13345224609.738474920.8088930838d88f008e0"},
+	}
+
+	return signedRequest(t, "/slack/commands", secret, "application/x-www-form-urlencoded", form.Encode())
+}
+
+func slashReply(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var reply struct {
+		ResponseType string `json:"response_type"`
+		Text         string `json:"text"`
+	}
+
+	err := json.Unmarshal(w.Body.Bytes(), &reply)
+	assert.Nil(t, err)
+	assert.Equal(t, "ephemeral", reply.ResponseType)
+
+	return reply.Text
+}
+
+func TestSlashCommandDispatch(t *testing.T) {
+	m, _, _ := slacktest.NewBot(t, "", nil, mcdowell.WithSigningSecret(testSigningSecret))
+
+	var got *mcdowell.CommandRequest
+
+	err := m.RegisterCommand(mcdowell.Command{
+		Name:        "greet",
+		Usage:       "greet <name>",
+		Description: "Greets someone the Zamundan way.",
+		Handler: func(b *mcdowell.Bot, req *mcdowell.CommandRequest) (string, error) {
+			got = req
+			return "Good morning, " + strings.Join(req.Args, " ") + "!", nil
+		},
+	})
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	m.HandleSlashCommand(w, slashCommand(t, testSigningSecret, "greet my neighbors"))
+
+	assert.Equal(t, "Good morning, my neighbors!", slashReply(t, w))
+	assert.Equal(t, "greet", got.Command)
+	assert.Equal(t, []string{"my", "neighbors"}, got.Args)
+	assert.Equal(t, "U0AKEEM", got.UserID)
+	assert.Equal(t, "akeem", got.UserName)
+	assert.Equal(t, "C0000GENERAL", got.ChannelID)
+	assert.NotEmpty(t, got.TriggerID)
+
+	w = httptest.NewRecorder()
+	m.HandleSlashCommand(w, slashCommand(t, testSigningSecret, ""))
+
+	help := slashReply(t, w)
+	assert.Contains(t, help, "`greet <name>` - Greets someone the Zamundan way.")
+	assert.Contains(t, help, "`help [command]`")
+
+	w = httptest.NewRecorder()
+	m.HandleSlashCommand(w, slashCommand(t, testSigningSecret, "help greet"))
+
+	assert.Equal(t, "`greet <name>` - Greets someone the Zamundan way.", slashReply(t, w))
+
+	w = httptest.NewRecorder()
+	m.HandleSlashCommand(w, slashCommand(t, testSigningSecret, "moonwalk"))
+
+	assert.Contains(t, slashReply(t, w), "I don't know how to `moonwalk`")
+}
+
+func TestSlashCommandRejectsBadSignatures(t *testing.T) {
+	m, _, _ := slacktest.NewBot(t, "", nil, mcdowell.WithSigningSecret(testSigningSecret))
+
+	w := httptest.NewRecorder()
+	m.HandleSlashCommand(w, slashCommand(t, "not the secret", "help"))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r := slashCommand(t, testSigningSecret, "help")
+	r.Header.Del("X-Slack-Signature")
+
+	w = httptest.NewRecorder()
+	m.HandleSlashCommand(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	r = slashCommand(t, testSigningSecret, "help")
+	r.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10))
+
+	w = httptest.NewRecorder()
+	m.HandleSlashCommand(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRegisterCommandValidation(t *testing.T) {
+	m, _, _ := slacktest.NewBot(t, "", nil, mcdowell.WithSigningSecret(testSigningSecret))
+
+	noop := func(*mcdowell.Bot, *mcdowell.CommandRequest) (string, error) { return "", nil }
+
+	assert.NotNil(t, m.RegisterCommand(mcdowell.Command{Name: "", Handler: noop}))
+	assert.NotNil(t, m.RegisterCommand(mcdowell.Command{Name: "two words", Handler: noop}))
+	assert.NotNil(t, m.RegisterCommand(mcdowell.Command{Name: "nohandler"}))
+	assert.NotNil(t, m.RegisterCommand(mcdowell.Command{Name: "help", Handler: noop}))
+}
@@ -0,0 +1,297 @@
+package mcdowell
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// Socket Mode envelope types.
+const (
+	socketModeHello         = "hello"
+	socketModeDisconnect    = "disconnect"
+	socketModeEventsAPI     = "events_api"
+	socketModeSlashCommands = "slash_commands"
+	socketModeInteractive   = "interactive"
+)
+
+// maxSocketModeBackoff caps how long to wait between reconnection attempts.
+const maxSocketModeBackoff = time.Minute
+
+// socketModeAnswerTimeout is how long to wait for the bot's answer to an
+// envelope before acknowledging it without one, inside the 3 seconds Slack
+// allows.
+const socketModeAnswerTimeout = 2 * time.Second
+
+var errSocketModeDisconnect = errors.New("disconnect requested by Slack")
+
+type (
+	// SocketMode is an EventSource receiving events over a Socket Mode
+	// websocket, so the bot doesn't need to be reachable from the internet.
+	SocketMode struct {
+		appToken   string
+		apiURL     string
+		httpClient *http.Client
+		dialer     *websocket.Dialer
+		delivered  *deliveredEvents
+		now        func() time.Time
+	}
+
+	// socketModeEnvelope wraps everything Slack sends over a Socket Mode connection.
+	socketModeEnvelope struct {
+		Type       string          `json:"type"`
+		EnvelopeID string          `json:"envelope_id"`
+		Payload    json.RawMessage `json:"payload"`
+		Reason     string          `json:"reason"`
+	}
+
+	// socketModeAck acknowledges an envelope was received, answering slash
+	// commands with their reply and modal submissions with any problems
+	// with them.
+	socketModeAck struct {
+		EnvelopeID string      `json:"envelope_id"`
+		Payload    interface{} `json:"payload,omitempty"`
+	}
+)
+
+// NewSocketMode returns a Socket Mode receiver connecting with appToken, an
+// app-level token with the connections:write scope.
+func NewSocketMode(appToken string, options ...func(*SocketMode)) *SocketMode {
+	s := &SocketMode{
+		appToken:   appToken,
+		apiURL:     slack.APIURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		dialer:     websocket.DefaultDialer,
+		delivered:  newDeliveredEvents(),
+		now:        time.Now,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// SocketModeAPIURL sets the Slack Web API URL used to open connections.
+func SocketModeAPIURL(url string) func(*SocketMode) {
+	return func(s *SocketMode) {
+		s.apiURL = url
+	}
+}
+
+// Listen implements EventSource. Dropped connections are reopened until ctx
+// is done; it only fails if the very first connection can't be opened.
+func (s *SocketMode) Listen(ctx context.Context, events chan<- interface{}) error {
+	backoff := time.Second
+	connected := false
+
+	for {
+		wsURL, err := s.openConnection(ctx)
+		if err == nil {
+			connected = true
+			backoff = time.Second
+
+			err = s.receive(ctx, wsURL, events)
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if !connected {
+			return err
+		}
+
+		if err == errSocketModeDisconnect {
+			log.Println("Slack asked to reconnect, opening a new Socket Mode connection...")
+			continue
+		}
+
+		log.Printf("Socket Mode connection failed: %v, reconnecting in %s\n", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+
+		backoff = min(2*backoff, maxSocketModeBackoff)
+	}
+}
+
+// openConnection asks Slack for a websocket URL to receive events on.
+func (s *SocketMode) openConnection(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.apiURL+"apps.connections.open", nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+s.appToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	var connection struct {
+		slack.SlackResponse
+		URL string `json:"url"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&connection); err != nil {
+		return "", errors.Wrapf(err, "unexpected apps.connections.open response (%s)", resp.Status)
+	}
+
+	if !connection.Ok {
+		return "", errors.Errorf("unable to open a Socket Mode connection: %s", connection.Error)
+	}
+
+	return connection.URL, nil
+}
+
+// receive reads envelopes from the websocket at wsURL, acknowledging each
+// and delivering the events they carry, until the connection drops. Modal
+// submissions are handled before they're acknowledged so any problems with
+// them can be shown.
+func (s *SocketMode) receive(ctx context.Context, wsURL string, events chan<- interface{}) error {
+	conn, _, err := s.dialer.Dial(wsURL, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer conn.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		var envelope socketModeEnvelope
+		if err := conn.ReadJSON(&envelope); err != nil {
+			return errors.WithStack(err)
+		}
+
+		event, err := s.decode(envelope)
+
+		ack := socketModeAck{EnvelopeID: envelope.EnvelopeID}
+
+		switch event.(type) {
+		case *ViewSubmission, *slack.SlashCommand:
+			ack.Payload = s.ask(ctx, event, events)
+			event = nil
+		}
+
+		if envelope.EnvelopeID != "" {
+			if err := conn.WriteJSON(ack); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+		if err == errSocketModeDisconnect {
+			return err
+		}
+
+		if err != nil {
+			log.Printf("unable to decode %s envelope %s: %v\n", envelope.Type, envelope.EnvelopeID, err)
+			continue
+		}
+
+		if event == nil {
+			continue
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// ask delivers event and waits for the bot's answer to acknowledge it with.
+func (s *SocketMode) ask(ctx context.Context, event interface{}, events chan<- interface{}) interface{} {
+	reply := make(chan interface{}, 1)
+
+	select {
+	case events <- &request{event: event, reply: reply}:
+	case <-ctx.Done():
+		return nil
+	}
+
+	select {
+	case answer := <-reply:
+		return answer
+	case <-ctx.Done():
+		return nil
+	case <-time.After(socketModeAnswerTimeout):
+		log.Printf("%T took too long to answer, acknowledging it without one\n", event)
+
+		if command, ok := event.(*slack.SlashCommand); ok {
+			go s.answerLate(command, reply)
+		}
+
+		return nil
+	}
+}
+
+// answerLate replies to command once the bot has an answer, for commands
+// that were acknowledged before it had one.
+func (s *SocketMode) answerLate(command *slack.SlashCommand, reply <-chan interface{}) {
+	msg, ok := (<-reply).(slack.Msg)
+	if !ok {
+		return
+	}
+
+	if err := respond(s.httpClient, command.ResponseURL, msg); err != nil {
+		log.Printf("unable to reply to %s %s: %v\n", command.Command, command.Text, err)
+	}
+}
+
+// decode returns the event carried by envelope, or nil if there's nothing to deliver.
+func (s *SocketMode) decode(envelope socketModeEnvelope) (interface{}, error) {
+	switch envelope.Type {
+	case socketModeHello:
+		log.Println("connected to Slack over Socket Mode")
+		return nil, nil
+	case socketModeDisconnect:
+		log.Println("Socket Mode disconnect requested:", envelope.Reason)
+		return nil, errSocketModeDisconnect
+	case socketModeEventsAPI:
+		var callback eventsAPIEnvelope
+		if err := json.Unmarshal(envelope.Payload, &callback); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if !s.delivered.firstDelivery(callback.EventID, s.now()) {
+			return nil, nil
+		}
+
+		return decodeEvent(callback.Event)
+	case socketModeSlashCommands:
+		var command slack.SlashCommand
+		if err := json.Unmarshal(envelope.Payload, &command); err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return &command, nil
+	case socketModeInteractive:
+		return decodeInteraction(envelope.Payload)
+	default:
+		return nil, nil
+	}
+}
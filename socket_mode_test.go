@@ -0,0 +1,185 @@
+package mcdowell_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+// socketModeAck is an acknowledgement the fake Slack received.
+type socketModeAck struct {
+	EnvelopeID string          `json:"envelope_id"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// startFakeSocketMode returns a fake Slack serving Socket Mode connections
+// which send envelopes, in order, and report every acknowledgement on acks.
+func startFakeSocketMode(t *testing.T, envelopes ...string) (*httptest.Server, <-chan socketModeAck) {
+	t.Helper()
+
+	acks := make(chan socketModeAck, len(envelopes))
+	upgrader := websocket.Upgrader{}
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+
+	mux.HandleFunc("/apps.connections.open", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Header.Get("Authorization") != "Bearer xapp-1-dummy" {
+			w.Write([]byte(`{"ok": false, "error": "invalid_auth"}`))
+			return
+		}
+
+		w.Write([]byte(`{"ok": true, "url": "ws` + strings.TrimPrefix(srv.URL, "http") + `/link"}`))
+	})
+
+	mux.HandleFunc("/link", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for _, envelope := range envelopes {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(envelope)); err != nil {
+				return
+			}
+
+			if !strings.Contains(envelope, "envelope_id") {
+				continue
+			}
+
+			var ack socketModeAck
+			if err := conn.ReadJSON(&ack); err != nil {
+				return
+			}
+
+			acks <- ack
+		}
+
+		conn.ReadMessage()
+	})
+
+	t.Cleanup(srv.Close)
+
+	return srv, acks
+}
+
+func TestSocketMode(t *testing.T) {
+	message := `{
+		"type": "events_api",
+		"envelope_id": "57d6a792-4d35-4d0b-b6aa-3361493e1caf",
+		"accepts_response_payload": false,
+		"payload": {
+			"type": "event_callback",
+			"event_id": "Ev0PV52K21",
+			"event": {
+				"type": "message",
+				"channel": "C0000GENERAL",
+				"user": "U0AKEEM",
+				"text": "soul glo"
+			}
+		}
+	}`
+
+	srv, acks := startFakeSocketMode(t,
+		`{"type": "hello", "num_connections": 1}`,
+		message,
+		strings.Replace(message, "57d6a792", "67d6a792", 1),
+		strings.NewReplacer("57d6a792", "77d6a792", "Ev0PV52K21", "Ev0PV52K22", "soul glo", "sexual chocolate").Replace(message),
+	)
+
+	events := listen(t, mcdowell.NewSocketMode("xapp-1-dummy", mcdowell.SocketModeAPIURL(srv.URL+"/")))
+
+	e, ok := nextEvent(t, events).(*slack.MessageEvent)
+	if assert.True(t, ok) {
+		assert.Equal(t, "C0000GENERAL", e.Channel)
+		assert.Equal(t, "soul glo", e.Text)
+	}
+
+	e, ok = nextEvent(t, events).(*slack.MessageEvent)
+	if assert.True(t, ok) {
+		assert.Equal(t, "sexual chocolate", e.Text, "the redelivered message should have been skipped")
+	}
+
+	assert.Equal(t, "57d6a792-4d35-4d0b-b6aa-3361493e1caf", (<-acks).EnvelopeID)
+	assert.Equal(t, "67d6a792-4d35-4d0b-b6aa-3361493e1caf", (<-acks).EnvelopeID)
+	assert.Equal(t, "77d6a792-4d35-4d0b-b6aa-3361493e1caf", (<-acks).EnvelopeID)
+}
+
+func TestSocketModeAnswersSlashCommands(t *testing.T) {
+	srv, acks := startFakeSocketMode(t, `{
+		"type": "slash_commands",
+		"envelope_id": "1d4c7f1e-2b2b-4bd9-9c52-7a8c3f6c0e9a",
+		"accepts_response_payload": true,
+		"payload": {
+			"command": "/mcdowell",
+			"text": "help",
+			"user_id": "U0AKEEM",
+			"channel_id": "C0000GENERAL"
+		}
+	}`)
+
+	p := &recordingPlugin{name: "recorder"}
+
+	m, _, _ := slacktest.NewBot(t, "", nil, mcdowell.WithPlugin(p))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go m.Listen(ctx, mcdowell.NewSocketMode("xapp-1-dummy", mcdowell.SocketModeAPIURL(srv.URL+"/")))
+
+	// The reply goes back in the acknowledgement, so it works in channels
+	// the bot isn't in.
+	ack := <-acks
+	assert.Equal(t, "1d4c7f1e-2b2b-4bd9-9c52-7a8c3f6c0e9a", ack.EnvelopeID)
+
+	var reply slack.Msg
+	assert.Nil(t, json.Unmarshal(ack.Payload, &reply))
+	assert.Equal(t, slack.ResponseTypeEphemeral, reply.ResponseType)
+	assert.Contains(t, reply.Text, "Here's what I can do:")
+
+	if assert.Len(t, p.events, 1) {
+		assert.Equal(t, "help", p.events[0].(*slack.SlashCommand).Text)
+	}
+}
+
+func TestSocketModeAnswersViewSubmissions(t *testing.T) {
+	submission := `{
+		"type": "interactive",
+		"envelope_id": "a1b2c3d4-0000-4bd9-9c52-7a8c3f6c0e9a",
+		"accepts_response_payload": true,
+		"payload": ` + jobSubmission + `
+	}`
+
+	answer := func(p *recordingPlugin) socketModeAck {
+		srv, acks := startFakeSocketMode(t, submission)
+
+		m, _, _ := slacktest.NewBot(t, "", nil, mcdowell.WithPlugin(p))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go m.Listen(ctx, mcdowell.NewSocketMode("xapp-1-dummy", mcdowell.SocketModeAPIURL(srv.URL+"/")))
+
+		return <-acks
+	}
+
+	ack := answer(&recordingPlugin{name: "jobs", fail: mcdowell.SubmissionErrors{"link": "Enter a link."}})
+	assert.Equal(t, "a1b2c3d4-0000-4bd9-9c52-7a8c3f6c0e9a", ack.EnvelopeID)
+	assert.JSONEq(t, `{"response_action": "errors", "errors": {"link": "Enter a link."}}`, string(ack.Payload))
+
+	ack = answer(&recordingPlugin{name: "jobs"})
+	assert.Equal(t, "a1b2c3d4-0000-4bd9-9c52-7a8c3f6c0e9a", ack.EnvelopeID)
+	assert.Empty(t, ack.Payload)
+}
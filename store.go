@@ -0,0 +1,231 @@
+package mcdowell
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned by a Store when a key doesn't exist.
+var ErrNotFound = errors.New("not found")
+
+type (
+	// Store persists the bot's state across restarts. Values are kept as
+	// opaque bytes under a key, grouped into buckets (e.g. one per feature).
+	// Implementations must be safe for concurrent use.
+	Store interface {
+		Get(bucket, key string) ([]byte, error)
+		Put(bucket, key string, value []byte) error
+		Delete(bucket, key string) error
+		Keys(bucket string) ([]string, error)
+		Close() error
+	}
+
+	// MemoryStore is a Store that keeps everything in memory, handy for tests
+	// and development.
+	MemoryStore struct {
+		mu      sync.RWMutex
+		buckets map[string]map[string][]byte
+	}
+
+	// FileStore is a Store backed by a single JSON file, rewritten atomically
+	// on every change.
+	FileStore struct {
+		MemoryStore
+		path string
+	}
+)
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]map[string][]byte{}}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(bucket, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return append([]byte(nil), value...), nil
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.put(bucket, key, value)
+
+	return nil
+}
+
+func (s *MemoryStore) put(bucket, key string, value []byte) {
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = map[string][]byte{}
+	}
+
+	s.buckets[bucket][key] = append([]byte(nil), value...)
+}
+
+// Delete implements Store. Deleting a key that doesn't exist isn't an error.
+func (s *MemoryStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.buckets[bucket], key)
+
+	return nil
+}
+
+// Keys implements Store, returning the keys in bucket in sorted order.
+func (s *MemoryStore) Keys(bucket string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var keys []string
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Close implements Store.
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// OpenFileStore opens the FileStore at path, creating it on first write if
+// it doesn't exist yet.
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{MemoryStore: *NewMemoryStore(), path: path}
+
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if err := json.Unmarshal(contents, &s.buckets); err != nil {
+		return nil, errors.Wrapf(err, "corrupt store %s", path)
+	}
+
+	if s.buckets == nil {
+		s.buckets = map[string]map[string][]byte{}
+	}
+
+	return s, nil
+}
+
+// Put implements Store.
+func (s *FileStore) Put(bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.buckets[bucket][key]
+	s.put(bucket, key, value)
+
+	if err := s.save(); err != nil {
+		if existed {
+			s.buckets[bucket][key] = previous
+		} else {
+			delete(s.buckets[bucket], key)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.buckets[bucket][key]
+	if !existed {
+		return nil
+	}
+
+	delete(s.buckets[bucket], key)
+
+	if err := s.save(); err != nil {
+		s.buckets[bucket][key] = previous
+		return err
+	}
+
+	return nil
+}
+
+// save writes every bucket to a temporary file and renames it over the store
+// so a crash mid-write never leaves a half written store behind.
+func (s *FileStore) save() error {
+	contents, err := json.Marshal(s.buckets)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.Rename(f.Name(), s.path))
+}
+
+// getJSON decodes the value stored under key into v.
+func getJSON(s Store, bucket, key string, v interface{}) error {
+	value, err := s.Get(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrapf(json.Unmarshal(value, v), "corrupt %s/%s", bucket, key)
+}
+
+// putJSON stores v under key as JSON.
+func putJSON(s Store, bucket, key string, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return s.Put(bucket, key, value)
+}
+
+// WithStore persists the bot's state in store rather than in memory.
+func WithStore(store Store) func(*Bot) {
+	return func(b *Bot) {
+		b.store = store
+	}
+}
@@ -0,0 +1,73 @@
+package mcdowell_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+)
+
+func TestStores(t *testing.T) {
+	fileStore, err := mcdowell.OpenFileStore(filepath.Join(t.TempDir(), "mcdowell.db"))
+	assert.Nil(t, err)
+
+	for name, store := range map[string]mcdowell.Store{
+		"memory": mcdowell.NewMemoryStore(),
+		"file":   fileStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := store.Get("karma", "U0SEMMI")
+			assert.Equal(t, mcdowell.ErrNotFound, err)
+
+			assert.Nil(t, store.Put("karma", "U0SEMMI", []byte("3")))
+			assert.Nil(t, store.Put("karma", "U0AKEEM", []byte("5")))
+			assert.Nil(t, store.Put("events", "U0SEMMI", []byte("elsewhere")))
+
+			value, err := store.Get("karma", "U0SEMMI")
+			assert.Nil(t, err)
+			assert.Equal(t, "3", string(value))
+
+			keys, err := store.Keys("karma")
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"U0AKEEM", "U0SEMMI"}, keys)
+
+			assert.Nil(t, store.Delete("karma", "U0SEMMI"))
+			assert.Nil(t, store.Delete("karma", "U0SEMMI"))
+
+			_, err = store.Get("karma", "U0SEMMI")
+			assert.Equal(t, mcdowell.ErrNotFound, err)
+
+			value, err = store.Get("events", "U0SEMMI")
+			assert.Nil(t, err)
+			assert.Equal(t, "elsewhere", string(value))
+
+			assert.Nil(t, store.Close())
+		})
+	}
+}
+
+func TestFileStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcdowell.db")
+
+	store, err := mcdowell.OpenFileStore(path)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Put("karma", "U0SEMMI", []byte("3")))
+	assert.Nil(t, store.Close())
+
+	reopened, err := mcdowell.OpenFileStore(path)
+	assert.Nil(t, err)
+
+	value, err := reopened.Get("karma", "U0SEMMI")
+	assert.Nil(t, err)
+	assert.Equal(t, "3", string(value))
+}
+
+func TestCorruptFileStoreIsRejected(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcdowell.db")
+	assert.Nil(t, os.WriteFile(path, []byte(`{"karma":`), 0600))
+
+	_, err := mcdowell.OpenFileStore(path)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,557 @@
+package mcdowell
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// Match modes a trigger can use to decide whether a message sets it off.
+// Matching is always case insensitive.
+const (
+	// MatchSubstring fires whenever the phrase appears anywhere in a message.
+	MatchSubstring = "substring"
+	// MatchWord fires when the phrase appears as whole word(s), so "queen"
+	// doesn't fire on "queensland".
+	MatchWord = "word"
+	// MatchExact fires only when the whole message is the phrase.
+	MatchExact = "exact"
+	// MatchPrefix fires when the message starts with the phrase.
+	MatchPrefix = "prefix"
+	// MatchRegex treats the phrase as a regular expression. Its capture groups
+	// can be referenced from the response as $1 or ${name}.
+	MatchRegex = "regex"
+)
+
+// MatchPolicy decides which of the triggers a message sets off actually respond.
+type MatchPolicy string
+
+// Match policies a bot can be configured with. Matching triggers are always
+// considered in precedence order: highest priority first, then catalog order,
+// up to and including the first matching trigger marked stop.
+const (
+	// FirstMatch responds with only the highest precedence matching trigger.
+	FirstMatch MatchPolicy = "first"
+	// AllMatches responds with every matching trigger, in precedence order.
+	AllMatches MatchPolicy = "all"
+	// RandomMatch responds with one matching trigger picked at random.
+	RandomMatch MatchPolicy = "random"
+)
+
+// DefaultTriggerReloadInterval is how often the trigger catalog file is checked for changes.
+const DefaultTriggerReloadInterval = 30 * time.Second
+
+type (
+	// Trigger describes a single canned reaction: the phrase that sets it off
+	// and what McDowell posts back when it does.
+	Trigger struct {
+		Phrase     string            `json:"phrase"`
+		Match      string            `json:"match,omitempty"`
+		Priority   int               `json:"priority,omitempty"`
+		Stop       bool              `json:"stop,omitempty"`
+		Text       string            `json:"text,omitempty"`
+		ImageURL   string            `json:"image_url,omitempty"`
+		Attachment *slack.Attachment `json:"attachment,omitempty"`
+		Responses  []Response        `json:"responses,omitempty"`
+		NoRepeat   int               `json:"no_repeat,omitempty"`
+		Cooldown   *Cooldown         `json:"cooldown,omitempty"`
+		Channels   *ChannelScope     `json:"channels,omitempty"`
+	}
+
+	// Response is one of the things a trigger can post back. A trigger picks
+	// one of its responses at random, favoring those with a higher weight.
+	Response struct {
+		Text       string            `json:"text,omitempty"`
+		ImageURL   string            `json:"image_url,omitempty"`
+		Attachment *slack.Attachment `json:"attachment,omitempty"`
+		Weight     int               `json:"weight,omitempty"`
+	}
+
+	// TriggerCatalog is the set of triggers the bot responds to.
+	TriggerCatalog struct {
+		Triggers []Trigger `json:"triggers"`
+	}
+
+	// trigger is a catalog entry compiled into a ready to use matcher.
+	trigger struct {
+		Trigger
+		pattern   *regexp.Regexp
+		allow     channelSet
+		deny      channelSet
+		responses []Response
+		recent    *recentResponses
+	}
+
+	// recentResponses remembers which of a trigger's responses were used last.
+	recentResponses struct {
+		mu      sync.Mutex
+		indices []int
+	}
+)
+
+const zamundaMoneyURL = "https://novembrepleut.files.wordpress.com/2011/06/zamundamoney_100.png"
+
+// DefaultTriggerCatalog is used whenever no trigger catalog file has been configured.
+var DefaultTriggerCatalog = TriggerCatalog{
+	Triggers: []Trigger{
+		{Phrase: "show me the money", Text: "The boy has got his own money!", ImageURL: zamundaMoneyURL},
+		{Phrase: "let me hold something", Text: "I got you!", ImageURL: zamundaMoneyURL},
+		{Phrase: "soul glo", ImageURL: "https://media.giphy.com/media/3Gz3vy81HkDa8/giphy.gif"},
+		{Phrase: "queen", Match: MatchWord, ImageURL: "https://img.memesuper.com/bc7ab2796bdb983d5434fc842efcee0b_coming-to-america-aha-meme-coming-to-america_500-263.gif"},
+	},
+}
+
+// LoadTriggerCatalog reads the JSON trigger catalog stored at path.
+func LoadTriggerCatalog(path string) (*TriggerCatalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	catalog, err := ParseTriggerCatalog(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid trigger catalog %s", path)
+	}
+
+	return catalog, nil
+}
+
+// ParseTriggerCatalog decodes and validates a JSON trigger catalog.
+func ParseTriggerCatalog(r io.Reader) (*TriggerCatalog, error) {
+	var catalog TriggerCatalog
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&catalog); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if _, err := catalog.compile(); err != nil {
+		return nil, err
+	}
+
+	return &catalog, nil
+}
+
+func (c TriggerCatalog) compile() ([]trigger, error) {
+	triggers := make([]trigger, 0, len(c.Triggers))
+
+	for i, t := range c.Triggers {
+		t.Phrase = strings.TrimSpace(t.Phrase)
+
+		if t.Phrase == "" {
+			return nil, errors.Errorf("trigger #%d has no phrase", i+1)
+		}
+
+		if t.Match == "" {
+			t.Match = MatchSubstring
+		}
+
+		pattern, err := compilePattern(t.Match, t.Phrase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "trigger %q", t.Phrase)
+		}
+
+		responses, err := t.pool()
+		if err != nil {
+			return nil, errors.Wrapf(err, "trigger %q", t.Phrase)
+		}
+
+		if t.NoRepeat < 0 {
+			return nil, errors.Errorf("trigger %q has a negative no_repeat", t.Phrase)
+		}
+
+		if t.Cooldown != nil {
+			if err := t.Cooldown.validate(); err != nil {
+				return nil, errors.Wrapf(err, "trigger %q", t.Phrase)
+			}
+		}
+
+		triggers = append(triggers, trigger{
+			Trigger:   t,
+			pattern:   pattern,
+			responses: responses,
+			recent:    &recentResponses{},
+		})
+	}
+
+	sort.SliceStable(triggers, func(i, j int) bool {
+		return triggers[i].Priority > triggers[j].Priority
+	})
+
+	return triggers, nil
+}
+
+// wordBoundary matches the edges of a word without relying on \b, which only
+// understands ASCII and phrases that begin and end with word characters.
+const wordBoundary = `[^\pL\pN_]`
+
+func compilePattern(mode, phrase string) (*regexp.Regexp, error) {
+	var expr string
+
+	switch mode {
+	case MatchSubstring:
+		expr = regexp.QuoteMeta(phrase)
+	case MatchWord:
+		expr = `(?:^|` + wordBoundary + `)` + regexp.QuoteMeta(phrase) + `(?:` + wordBoundary + `|$)`
+	case MatchExact:
+		expr = `^` + regexp.QuoteMeta(phrase) + `$`
+	case MatchPrefix:
+		expr = `^` + regexp.QuoteMeta(phrase)
+	case MatchRegex:
+		expr = phrase
+	default:
+		return nil, errors.Errorf("unknown match mode %q", mode)
+	}
+
+	pattern, err := regexp.Compile(`(?is)` + expr)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return pattern, nil
+}
+
+// match returns the submatch indices of text if it sets off the trigger, nil otherwise.
+func (t trigger) match(text string) []int {
+	return t.pattern.FindStringSubmatchIndex(text)
+}
+
+// triggerMatch is a trigger set off by a particular message.
+type triggerMatch struct {
+	trigger
+	submatch []int
+}
+
+// allowedIn reports whether the trigger may respond in channel.
+func (t trigger) allowedIn(channel string) bool {
+	if t.deny != nil && t.deny.contains(channel) {
+		return false
+	}
+
+	if t.allow != nil {
+		return t.allow.contains(channel)
+	}
+
+	return true
+}
+
+// matchTriggers returns the triggers text sets off in channel which should respond under policy.
+func matchTriggers(triggers []trigger, channel, text string, policy MatchPolicy) []triggerMatch {
+	var matches []triggerMatch
+
+	for _, t := range triggers {
+		if !t.allowedIn(channel) {
+			continue
+		}
+
+		submatch := t.match(text)
+		if submatch == nil {
+			continue
+		}
+
+		matches = append(matches, triggerMatch{trigger: t, submatch: submatch})
+
+		if t.Stop {
+			break
+		}
+	}
+
+	if len(matches) == 0 {
+		return nil
+	}
+
+	switch policy {
+	case FirstMatch:
+		return matches[:1]
+	case RandomMatch:
+		i := rand.Intn(len(matches))
+		return matches[i : i+1]
+	default:
+		return matches
+	}
+}
+
+// expand interpolates captured groups into template for regular expression triggers.
+func (t trigger) expand(template, text string, submatch []int) string {
+	if t.Match != MatchRegex || template == "" {
+		return template
+	}
+
+	return string(t.pattern.ExpandString(nil, template, text, submatch))
+}
+
+// pool returns every response the trigger can pick from: the one given
+// inline, if any, followed by its responses.
+func (t Trigger) pool() ([]Response, error) {
+	var responses []Response
+
+	if t.Text != "" || t.ImageURL != "" || t.Attachment != nil {
+		responses = append(responses, Response{Text: t.Text, ImageURL: t.ImageURL, Attachment: t.Attachment})
+	}
+
+	for i, response := range t.Responses {
+		if response.Text == "" && response.ImageURL == "" && response.Attachment == nil {
+			return nil, errors.Errorf("response #%d is empty", i+1)
+		}
+
+		if response.Weight < 0 {
+			return nil, errors.Errorf("response #%d has a negative weight", i+1)
+		}
+
+		responses = append(responses, response)
+	}
+
+	if len(responses) == 0 {
+		return nil, errors.New("no response")
+	}
+
+	return responses, nil
+}
+
+// pick chooses one of the trigger's responses by weight, skipping the last
+// NoRepeat responses it picked whenever there are others to choose from.
+func (t trigger) pick() Response {
+	t.recent.mu.Lock()
+	defer t.recent.mu.Unlock()
+
+	excluded := map[int]bool{}
+	for _, i := range t.recent.indices {
+		excluded[i] = true
+	}
+
+	var candidates []int
+	var total int
+
+	for i, response := range t.responses {
+		if !excluded[i] {
+			candidates = append(candidates, i)
+			total += response.weight()
+		}
+	}
+
+	chosen := candidates[len(candidates)-1]
+
+	n := rand.Intn(total)
+	for _, i := range candidates {
+		if n -= t.responses[i].weight(); n < 0 {
+			chosen = i
+			break
+		}
+	}
+
+	if limit := min(t.NoRepeat, len(t.responses)-1); limit > 0 {
+		t.recent.indices = append(t.recent.indices, chosen)
+
+		if len(t.recent.indices) > limit {
+			t.recent.indices = t.recent.indices[len(t.recent.indices)-limit:]
+		}
+	}
+
+	return t.responses[chosen]
+}
+
+func (r Response) weight() int {
+	if r.Weight == 0 {
+		return 1
+	}
+
+	return r.Weight
+}
+
+// respond posts one of the trigger's responses to the channel event came from.
+func (t trigger) respond(b *Bot, event *slack.MessageEvent, text string, submatch []int) error {
+	response := t.pick()
+
+	var attachment slack.Attachment
+	if response.Attachment != nil {
+		attachment = *response.Attachment
+	}
+
+	if response.Text != "" {
+		attachment.Text = response.Text
+	}
+
+	if response.ImageURL != "" {
+		attachment.ImageURL = response.ImageURL
+	}
+
+	attachment.Text = t.expand(attachment.Text, text, submatch)
+	attachment.Pretext = t.expand(attachment.Pretext, text, submatch)
+	attachment.Title = t.expand(attachment.Title, text, submatch)
+	attachment.Fallback = t.expand(attachment.Fallback, text, submatch)
+
+	_, _, err := b.client.PostMessage(event.Channel,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionEnableLinkUnfurl(),
+		slack.MsgOptionAttachments(attachment),
+	)
+	return err
+}
+
+// loadTriggers reads, compiles and swaps in the trigger catalog. The file
+// watcher, SIGHUP and the reload command can all reload at once, so one load
+// runs at a time and an older catalog can't replace a newer one.
+func (b *Bot) loadTriggers() error {
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
+
+	catalog := &DefaultTriggerCatalog
+
+	if b.triggerCatalog != "" {
+		var err error
+
+		catalog, err = LoadTriggerCatalog(b.triggerCatalog)
+		if err != nil {
+			return err
+		}
+	}
+
+	triggers, err := catalog.compile()
+	if err != nil {
+		return err
+	}
+
+	err = b.scopeTriggers(triggers)
+	if err != nil {
+		return err
+	}
+
+	b.triggersMu.Lock()
+	b.triggers = triggers
+	b.triggersMu.Unlock()
+
+	if b.Debug {
+		log.Printf("loaded %d trigger(s)\n", len(triggers))
+	}
+
+	return nil
+}
+
+// scopeTriggers resolves the channels each trigger is scoped to, looking up
+// any given by name with the Slack client.
+func (b *Bot) scopeTriggers(triggers []trigger) error {
+	var names []string
+	for _, t := range triggers {
+		if t.Channels != nil {
+			names = append(names, t.Channels.names()...)
+		}
+	}
+
+	ids := map[string]string{}
+
+	if len(names) > 0 {
+		var err error
+
+		ids, err = b.channelIDs()
+		if err != nil {
+			return errors.Wrap(err, "unable to resolve trigger channels")
+		}
+	}
+
+	for i, t := range triggers {
+		if t.Channels == nil {
+			continue
+		}
+
+		if len(t.Channels.Allow) > 0 {
+			triggers[i].allow = b.resolveChannels(t.Channels.Allow, ids)
+		}
+
+		if len(t.Channels.Deny) > 0 {
+			triggers[i].deny = b.resolveChannels(t.Channels.Deny, ids)
+		}
+	}
+
+	return nil
+}
+
+// activeTriggers returns the trigger set currently in effect. The returned
+// slice is never modified, reloads swap in a new one instead.
+func (b *Bot) activeTriggers() []trigger {
+	b.triggersMu.RLock()
+	defer b.triggersMu.RUnlock()
+
+	return b.triggers
+}
+
+// ReloadTriggers re-reads the trigger catalog and atomically swaps it in. If
+// the catalog can't be loaded the previous triggers stay active.
+func (b *Bot) ReloadTriggers() error {
+	err := b.loadTriggers()
+	if err != nil {
+		log.Println("keeping previous trigger catalog, reload failed:", err)
+		return err
+	}
+
+	log.Println("reloaded trigger catalog", b.triggerCatalog)
+
+	return nil
+}
+
+// watchTriggerCatalog polls the trigger catalog file and reloads it whenever
+// it differs from last, until the bot's context is done.
+func (b *Bot) watchTriggerCatalog(last os.FileInfo) {
+	ticker := time.NewTicker(b.triggerReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := os.Stat(b.triggerCatalog)
+		if err != nil {
+			if b.Debug {
+				log.Println("unable to stat trigger catalog:", err)
+			}
+			continue
+		}
+
+		if last != nil && current.ModTime().Equal(last.ModTime()) && current.Size() == last.Size() {
+			continue
+		}
+
+		last = current
+
+		_ = b.ReloadTriggers()
+	}
+}
+
+// WithTriggerCatalog loads the bot's triggers from the JSON catalog at path
+// instead of the built in defaults.
+func WithTriggerCatalog(path string) func(*Bot) {
+	return func(b *Bot) {
+		b.triggerCatalog = path
+	}
+}
+
+// WithMatchPolicy sets how the bot responds when a message sets off more than
+// one trigger. Bots respond to AllMatches by default.
+func WithMatchPolicy(policy MatchPolicy) func(*Bot) {
+	return func(b *Bot) {
+		b.matchPolicy = policy
+	}
+}
+
+// WithTriggerReloadInterval sets how often the trigger catalog file is checked
+// for changes. A zero interval disables watching; ReloadTriggers still works.
+func WithTriggerReloadInterval(interval time.Duration) func(*Bot) {
+	return func(b *Bot) {
+		b.triggerReloadInterval = interval
+	}
+}
@@ -0,0 +1,380 @@
+package mcdowell_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+func TestTriggerCatalogFromFile(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog("testdata/triggers.json"))
+
+	e := &slack.MessageEvent{
+		Msg: slack.Msg{
+			Channel: "#general",
+			User:    "willmadison",
+			Text:    "Ladies and gentlemen... SEXUAL CHOCOLATE!",
+		},
+	}
+
+	err := m.OnNewMessage(e)
+	assert.Nil(t, err)
+
+	assert.Equal(t, e.Channel, fake.Last().Form.Get("channel"))
+
+	var actual_attachments []slack.Attachment
+
+	err = json.Unmarshal([]byte(fake.Last().Form.Get("attachments")), &actual_attachments)
+	assert.Nil(t, err)
+
+	assert.Len(t, actual_attachments, 1)
+	assert.Equal(t, "Sexual Chocolate! Yeah!", actual_attachments[0].Text)
+	assert.Equal(t, "https://media.giphy.com/media/sexual-chocolate/giphy.gif", actual_attachments[0].ImageURL)
+	assert.Equal(t, "Randy Watson", actual_attachments[0].Title)
+	assert.Equal(t, "#8B4513", actual_attachments[0].Color)
+}
+
+func TestTriggerCatalogReplacesDefaults(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog("testdata/triggers.json"))
+
+	err := m.OnNewMessage(&slack.MessageEvent{
+		Msg: slack.Msg{
+			Channel: "#general",
+			User:    "willmadison",
+			Text:    "They gonna have to show me the money!",
+		},
+	})
+	assert.Nil(t, err)
+
+	assert.Empty(t, fake.All())
+}
+
+func TestMalformedTriggerCatalogIsRejected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := slacktest.NewServer(t, nil)
+
+	_, err := mcdowell.NewBot(ctx, fake.Client(), mcdowell.WithTesting(), mcdowell.WithTriggerCatalog("testdata/triggers_malformed.json"))
+	assert.NotNil(t, err)
+
+	_, err = mcdowell.ParseTriggerCatalog(strings.NewReader(`{"triggers": [{"phrase": "queen", "match": "telepathy", "text": "?"}]}`))
+	assert.NotNil(t, err)
+
+	_, err = mcdowell.ParseTriggerCatalog(strings.NewReader(`{"triggers": [{"phrase": "queen", "txet": "typo"}]}`))
+	assert.NotNil(t, err)
+}
+
+func writeCatalog(t *testing.T, path, contents string) {
+	t.Helper()
+
+	err := os.WriteFile(path, []byte(contents), 0o644)
+	assert.Nil(t, err)
+}
+
+func TestReloadTriggers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+	writeCatalog(t, path, `{"triggers": [{"phrase": "soul glo", "text": "just let your soul glo"}]}`)
+
+	m, fake, _ := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog(path), mcdowell.WithTriggerReloadInterval(0))
+
+	e := &slack.MessageEvent{
+		Msg: slack.Msg{
+			Channel: "#general",
+			User:    "willmadison",
+			Text:    "soul glo",
+		},
+	}
+
+	attachmentText := func() string {
+		var actual_attachments []slack.Attachment
+
+		err := json.Unmarshal([]byte(fake.Last().Form.Get("attachments")), &actual_attachments)
+		assert.Nil(t, err)
+		assert.Len(t, actual_attachments, 1)
+
+		return actual_attachments[0].Text
+	}
+
+	assert.Nil(t, m.OnNewMessage(e))
+	assert.Equal(t, "just let your soul glo", attachmentText())
+
+	writeCatalog(t, path, `{"triggers": [{"phrase": "soul glo", "text": "feel it, feel it"}]}`)
+	assert.Nil(t, m.ReloadTriggers())
+
+	assert.Nil(t, m.OnNewMessage(e))
+	assert.Equal(t, "feel it, feel it", attachmentText())
+
+	writeCatalog(t, path, `{"triggers": [{"phrase": "soul glo"`)
+	assert.NotNil(t, m.ReloadTriggers())
+
+	assert.Nil(t, m.OnNewMessage(e))
+	assert.Equal(t, "feel it, feel it", attachmentText())
+}
+
+func TestTriggerCatalogIsWatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+	writeCatalog(t, path, `{"triggers": [{"phrase": "soul glo", "text": "just let your soul glo"}]}`)
+
+	m, fake, _ := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog(path), mcdowell.WithTriggerReloadInterval(10*time.Millisecond))
+
+	writeCatalog(t, path, `{"triggers": [{"phrase": "jheri curl", "text": "don't touch the couch"}]}`)
+
+	e := &slack.MessageEvent{
+		Msg: slack.Msg{
+			Channel: "#general",
+			User:    "willmadison",
+			Text:    "nice jheri curl",
+		},
+	}
+
+	fake.Reset()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for fake.Last().Method == "" && time.Now().Before(deadline) {
+		assert.Nil(t, m.OnNewMessage(e))
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, "chat.postMessage", fake.Last().Method)
+}
+
+func TestTriggerMatchModes(t *testing.T) {
+	cases := []struct {
+		match    string
+		phrase   string
+		text     string
+		expected bool
+	}{
+		{mcdowell.MatchSubstring, "queen", "Greetings from Queensland", true},
+		{mcdowell.MatchWord, "queen", "Greetings from Queensland", false},
+		{mcdowell.MatchWord, "queen", "Where is my QUEEN?", true},
+		{mcdowell.MatchWord, "queen to be", "the queen to be has arrived", true},
+		{mcdowell.MatchExact, "soul glo", "Soul Glo", true},
+		{mcdowell.MatchExact, "soul glo", "just let your soul glo", false},
+		{mcdowell.MatchPrefix, "good morning", "Good morning, my neighbors!", true},
+		{mcdowell.MatchPrefix, "good morning", "I said good morning", false},
+		{mcdowell.MatchRegex, `^sexual (chocolate|vanilla)$`, "Sexual Chocolate", true},
+		{mcdowell.MatchRegex, `^sexual (chocolate|vanilla)$`, "sexual healing", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.match+"/"+c.text, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "triggers.json")
+			catalog, err := json.Marshal(mcdowell.TriggerCatalog{
+				Triggers: []mcdowell.Trigger{{Phrase: c.phrase, Match: c.match, Text: "matched"}},
+			})
+			assert.Nil(t, err)
+			writeCatalog(t, path, string(catalog))
+
+			m, fake, _ := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog(path))
+
+			err = m.OnNewMessage(&slack.MessageEvent{
+				Msg: slack.Msg{
+					Channel: "#general",
+					User:    "willmadison",
+					Text:    c.text,
+				},
+			})
+			assert.Nil(t, err)
+
+			assert.Equal(t, c.expected, fake.Last().Method != "")
+		})
+	}
+}
+
+func TestRegexTriggerInterpolatesCaptures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+	writeCatalog(t, path, `{
+		"triggers": [
+			{
+				"phrase": "welcome to (?P<place>[a-z ]+?)[.!]*$",
+				"match": "regex",
+				"text": "${place}? Is that anywhere near Zamunda, $1?",
+				"attachment": {"title": "Welcome to ${place}"}
+			}
+		]
+	}`)
+
+	m, fake, _ := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog(path))
+
+	err := m.OnNewMessage(&slack.MessageEvent{
+		Msg: slack.Msg{
+			Channel: "#general",
+			User:    "willmadison",
+			Text:    "Welcome to Queens!",
+		},
+	})
+	assert.Nil(t, err)
+
+	var actual_attachments []slack.Attachment
+
+	err = json.Unmarshal([]byte(fake.Last().Form.Get("attachments")), &actual_attachments)
+	assert.Nil(t, err)
+
+	assert.Len(t, actual_attachments, 1)
+	assert.Equal(t, "Queens? Is that anywhere near Zamunda, Queens?", actual_attachments[0].Text)
+	assert.Equal(t, "Welcome to Queens", actual_attachments[0].Title)
+}
+
+func TestInvalidRegexTriggerIsRejected(t *testing.T) {
+	_, err := mcdowell.ParseTriggerCatalog(strings.NewReader(`{"triggers": [{"phrase": "(unclosed", "match": "regex", "text": "?"}]}`))
+	assert.NotNil(t, err)
+}
+
+func attachmentTexts(t *testing.T, calls []slacktest.Call) []string {
+	t.Helper()
+
+	var texts []string
+	for _, call := range calls {
+		var attachments []slack.Attachment
+
+		err := json.Unmarshal([]byte(call.Form.Get("attachments")), &attachments)
+		assert.Nil(t, err)
+
+		for _, attachment := range attachments {
+			texts = append(texts, attachment.Text)
+		}
+	}
+
+	return texts
+}
+
+const precedenceCatalog = `{
+	"triggers": [
+		{"phrase": "money", "text": "money"},
+		{"phrase": "show me", "text": "show me", "priority": 5},
+		{"phrase": "the money", "text": "the money", "priority": 10},
+		{"phrase": "gonna", "text": "gonna", "priority": 1, "stop": true},
+		{"phrase": "have to", "text": "have to"}
+	]
+}`
+
+func TestTriggerPrecedence(t *testing.T) {
+	cases := []struct {
+		policy   mcdowell.MatchPolicy
+		text     string
+		expected []string
+	}{
+		{mcdowell.AllMatches, "show me the money", []string{"the money", "show me", "money"}},
+		{mcdowell.FirstMatch, "show me the money", []string{"the money"}},
+		{mcdowell.AllMatches, "They gonna have to show me the money!", []string{"the money", "show me", "gonna"}},
+		{mcdowell.AllMatches, "you have to pay me", []string{"have to"}},
+	}
+
+	for _, c := range cases {
+		t.Run(string(c.policy)+"/"+c.text, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "triggers.json")
+			writeCatalog(t, path, precedenceCatalog)
+
+			m, fake, _ := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog(path), mcdowell.WithMatchPolicy(c.policy))
+
+			err := m.OnNewMessage(&slack.MessageEvent{
+				Msg: slack.Msg{
+					Channel: "#general",
+					User:    "willmadison",
+					Text:    c.text,
+				},
+			})
+			assert.Nil(t, err)
+
+			assert.Equal(t, c.expected, attachmentTexts(t, fake.Calls("chat.postMessage")))
+		})
+	}
+}
+
+func TestRandomMatchPolicyRespondsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+	writeCatalog(t, path, precedenceCatalog)
+
+	m, fake, _ := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog(path), mcdowell.WithMatchPolicy(mcdowell.RandomMatch))
+
+	for i := 0; i < 10; i++ {
+		fake.Reset()
+
+		err := m.OnNewMessage(&slack.MessageEvent{
+			Msg: slack.Msg{
+				Channel: "#general",
+				User:    "willmadison",
+				Text:    "show me the money",
+			},
+		})
+		assert.Nil(t, err)
+
+		texts := attachmentTexts(t, fake.Calls("chat.postMessage"))
+		assert.Len(t, texts, 1)
+		assert.Contains(t, []string{"the money", "show me", "money"}, texts[0])
+	}
+}
+
+func TestTriggerResponsePools(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "triggers.json")
+	writeCatalog(t, path, `{
+		"triggers": [
+			{
+				"phrase": "queen",
+				"text": "A queen to be!",
+				"responses": [
+					{"text": "She must be able to think for herself.", "weight": 3},
+					{"image_url": "https://media.giphy.com/media/queen/giphy.gif"},
+					{"attachment": {"text": "Imani Izzi", "title": "Rose Bearer"}}
+				],
+				"no_repeat": 3
+			}
+		]
+	}`)
+
+	m, fake, _ := slacktest.NewBot(t, "", nil, mcdowell.WithTriggerCatalog(path))
+
+	e := &slack.MessageEvent{
+		Msg: slack.Msg{
+			Channel: "#general",
+			User:    "willmadison",
+			Text:    "I'm just looking for a queen",
+		},
+	}
+
+	var picks []string
+	for i := 0; i < 20; i++ {
+		fake.Reset()
+
+		assert.Nil(t, m.OnNewMessage(e))
+
+		var actual_attachments []slack.Attachment
+
+		err := json.Unmarshal([]byte(fake.Last().Form.Get("attachments")), &actual_attachments)
+		assert.Nil(t, err)
+		assert.Len(t, actual_attachments, 1)
+
+		picks = append(picks, actual_attachments[0].Text+actual_attachments[0].ImageURL)
+	}
+
+	for i := 3; i < len(picks); i++ {
+		window := map[string]bool{}
+		for _, pick := range picks[i-3 : i+1] {
+			window[pick] = true
+		}
+
+		assert.Len(t, window, 4, "expected no repeats in %v", picks[i-3:i+1])
+	}
+}
+
+func TestInvalidResponsePoolIsRejected(t *testing.T) {
+	for _, catalog := range []string{
+		`{"triggers": [{"phrase": "queen"}]}`,
+		`{"triggers": [{"phrase": "queen", "responses": [{}]}]}`,
+		`{"triggers": [{"phrase": "queen", "responses": [{"text": "?", "weight": -1}]}]}`,
+		`{"triggers": [{"phrase": "queen", "text": "?", "no_repeat": -1}]}`,
+	} {
+		_, err := mcdowell.ParseTriggerCatalog(strings.NewReader(catalog))
+		assert.NotNil(t, err, catalog)
+	}
+}
@@ -0,0 +1,223 @@
+package mcdowell
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// viewSubmissionType is the interaction type of modal submissions.
+const viewSubmissionType = "view_submission"
+
+// MBTInput is the type of input blocks, which only modals can hold.
+const MBTInput slack.MessageBlockType = "input"
+
+// Modal input element types.
+const (
+	ElementPlainTextInput = "plain_text_input"
+	ElementStaticSelect   = "static_select"
+)
+
+type (
+	// ModalView is a Block Kit modal, opened with Bot.OpenView. Its
+	// CallbackID identifies its submissions, and PrivateMetadata comes back
+	// with them, e.g. to remember the channel it was opened from.
+	ModalView struct {
+		Type            string                 `json:"type"`
+		CallbackID      string                 `json:"callback_id,omitempty"`
+		Title           *slack.TextBlockObject `json:"title"`
+		Submit          *slack.TextBlockObject `json:"submit,omitempty"`
+		Close           *slack.TextBlockObject `json:"close,omitempty"`
+		PrivateMetadata string                 `json:"private_metadata,omitempty"`
+		Blocks          []slack.Block          `json:"blocks"`
+	}
+
+	// InputBlock is a labelled field in a modal. Its BlockID names the
+	// field in ViewSubmission.Values and SubmissionErrors.
+	InputBlock struct {
+		Type     slack.MessageBlockType `json:"type"`
+		BlockID  string                 `json:"block_id"`
+		Label    *slack.TextBlockObject `json:"label"`
+		Element  *InputElement          `json:"element"`
+		Hint     *slack.TextBlockObject `json:"hint,omitempty"`
+		Optional bool                   `json:"optional,omitempty"`
+	}
+
+	// InputElement is what's filled in in an input block: free text, or one
+	// of a static list of Options.
+	InputElement struct {
+		Type          string                 `json:"type"`
+		ActionID      string                 `json:"action_id"`
+		Placeholder   *slack.TextBlockObject `json:"placeholder,omitempty"`
+		InitialValue  string                 `json:"initial_value,omitempty"`
+		Multiline     bool                   `json:"multiline,omitempty"`
+		Options       []*InputOption         `json:"options,omitempty"`
+		InitialOption *InputOption           `json:"initial_option,omitempty"`
+	}
+
+	// InputOption is one of the choices in a select element.
+	InputOption struct {
+		Text  *slack.TextBlockObject `json:"text"`
+		Value string                 `json:"value"`
+	}
+
+	// ViewSubmission is someone submitting a modal the bot opened. Handlers
+	// for EventViewSubmission can return SubmissionErrors to keep the modal
+	// open and show what's wrong.
+	ViewSubmission struct {
+		Type      string     `json:"type"`
+		Team      slack.Team `json:"team"`
+		User      slack.User `json:"user"`
+		TriggerID string     `json:"trigger_id"`
+		View      struct {
+			ID              string `json:"id"`
+			CallbackID      string `json:"callback_id"`
+			PrivateMetadata string `json:"private_metadata"`
+			State           struct {
+				Values map[string]map[string]viewStateValue `json:"values"`
+			} `json:"state"`
+		} `json:"view"`
+	}
+
+	// viewStateValue is what was entered in a modal's input element.
+	viewStateValue struct {
+		Type           string       `json:"type"`
+		Value          string       `json:"value"`
+		SelectedOption *InputOption `json:"selected_option"`
+	}
+
+	// viewErrors answers a submission Slack should keep the modal open for.
+	viewErrors struct {
+		ResponseAction string            `json:"response_action"`
+		Errors         map[string]string `json:"errors"`
+	}
+)
+
+// NewModalView returns a modal titled title with a submit button labelled submit.
+func NewModalView(callbackID, title, submit string, blocks ...slack.Block) ModalView {
+	return ModalView{
+		Type:       "modal",
+		CallbackID: callbackID,
+		Title:      slack.NewTextBlockObject(slack.PlainTextType, title, false, false),
+		Submit:     slack.NewTextBlockObject(slack.PlainTextType, submit, false, false),
+		Blocks:     blocks,
+	}
+}
+
+// NewInputBlock returns a field labelled label, named blockID, filled in with element.
+func NewInputBlock(blockID, label string, element *InputElement) *InputBlock {
+	element.ActionID = blockID
+
+	return &InputBlock{
+		Type:    MBTInput,
+		BlockID: blockID,
+		Label:   slack.NewTextBlockObject(slack.PlainTextType, label, false, false),
+		Element: element,
+	}
+}
+
+// BlockType implements slack.Block.
+func (b InputBlock) BlockType() slack.MessageBlockType {
+	return b.Type
+}
+
+// NewTextInput returns a free text element, with placeholder shown while it's empty.
+func NewTextInput(placeholder string) *InputElement {
+	e := &InputElement{Type: ElementPlainTextInput}
+
+	if placeholder != "" {
+		e.Placeholder = slack.NewTextBlockObject(slack.PlainTextType, placeholder, false, false)
+	}
+
+	return e
+}
+
+// NewSelectInput returns an element to pick one of options with.
+func NewSelectInput(placeholder string, options ...*InputOption) *InputElement {
+	return &InputElement{
+		Type:        ElementStaticSelect,
+		Placeholder: slack.NewTextBlockObject(slack.PlainTextType, placeholder, false, false),
+		Options:     options,
+	}
+}
+
+// NewInputOption returns a choice labelled text, submitted as value.
+func NewInputOption(value, text string) *InputOption {
+	return &InputOption{Text: slack.NewTextBlockObject(slack.PlainTextType, text, false, false), Value: value}
+}
+
+// Values returns what was entered in each of the modal's fields, by block ID.
+func (s *ViewSubmission) Values() map[string]string {
+	values := map[string]string{}
+
+	for blockID, actions := range s.View.State.Values {
+		for _, v := range actions {
+			if v.SelectedOption != nil {
+				values[blockID] = v.SelectedOption.Value
+			} else {
+				values[blockID] = v.Value
+			}
+		}
+	}
+
+	return values
+}
+
+// OpenView shows view to whoever triggerID came from, e.g. the person who
+// ran a slash command or clicked a button.
+func (b *Bot) OpenView(triggerID string, view ModalView) error {
+	if b.apiToken == "" {
+		return errors.New("no API token to open views with")
+	}
+
+	body, err := json.Marshal(struct {
+		TriggerID string    `json:"trigger_id"`
+		View      ModalView `json:"view"`
+	}{triggerID, view})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequestWithContext(b.ctx, http.MethodPost, b.apiURL+"views.open", bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+b.apiToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	var opened slack.SlackResponse
+	if err := json.NewDecoder(resp.Body).Decode(&opened); err != nil {
+		return errors.Wrapf(err, "unexpected views.open response (%s)", resp.Status)
+	}
+
+	if !opened.Ok {
+		return errors.Errorf("unable to open view %s: %s", view.CallbackID, opened.Error)
+	}
+
+	return nil
+}
+
+// WithAPIToken sets the bot token used for Web API methods the Slack client
+// doesn't support, like views.open.
+func WithAPIToken(token string) func(*Bot) {
+	return func(b *Bot) {
+		b.apiToken = token
+	}
+}
+
+// WithAPIURL sets the Slack Web API URL those methods are called at.
+func WithAPIURL(url string) func(*Bot) {
+	return func(b *Bot) {
+		b.apiURL = url
+	}
+}
@@ -0,0 +1,157 @@
+package mcdowell
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// DefaultWelcomeTemplate is the welcome DM newcomers get unless the config
+// provides one of its own.
+const DefaultWelcomeTemplate = `Yo {{.Name}}!
+
+I’d like to welcome you to the Atlanta Black Tech Family. Our mission is to improve the quality, quantity, and connections for people of African descent within the overall Metro Atlanta tech ecosystem.
+
+Please click on “Channels” to browse all of our sub-communities, and join the ones that are most relevant to you. Enjoy your time, and help us build the communities by inviting others in your network.`
+
+type (
+	// WelcomeConfig customizes the welcome DM. The message is a text/template
+	// given inline or read from a file and rendered with a Welcome.
+	WelcomeConfig struct {
+		Template         string   `json:"template,omitempty"`
+		TemplateFile     string   `json:"template_file,omitempty"`
+		FeaturedChannels []string `json:"featured_channels,omitempty"`
+	}
+
+	// Welcome holds the variables available to the welcome template.
+	Welcome struct {
+		Mention          string
+		Name             string
+		DisplayName      string
+		RealName         string
+		JoinDate         time.Time
+		MemberCount      int
+		FeaturedChannels []FeaturedChannel
+		Introductions    FeaturedChannel
+	}
+
+	// FeaturedChannel is a channel organizers want newcomers to know about.
+	// It prints as a link to the channel.
+	FeaturedChannel struct {
+		ID   string
+		Name string
+	}
+)
+
+func (c FeaturedChannel) String() string {
+	return "<#" + c.ID + ">"
+}
+
+func (c *WelcomeConfig) validate() error {
+	_, err := c.template()
+	return err
+}
+
+// template parses the configured welcome template, falling back to
+// DefaultWelcomeTemplate.
+func (c *WelcomeConfig) template() (*template.Template, error) {
+	switch {
+	case c == nil:
+		return parseTemplate("welcome", "", DefaultWelcomeTemplate)
+	case c.Template != "" && c.TemplateFile != "":
+		return nil, errors.New("template and template_file are mutually exclusive")
+	case c.TemplateFile != "":
+		contents, err := os.ReadFile(c.TemplateFile)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		return parseTemplate("welcome", string(contents), DefaultWelcomeTemplate)
+	default:
+		return parseTemplate("welcome", c.Template, DefaultWelcomeTemplate)
+	}
+}
+
+// parseTemplate parses text as the template called name, or fallback if text
+// is empty.
+func parseTemplate(name, text, fallback string) (*template.Template, error) {
+	if text == "" {
+		text = fallback
+	}
+
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s template", name)
+	}
+
+	return t, nil
+}
+
+// render executes t with data.
+func render(t *template.Template, data interface{}) (string, error) {
+	var text bytes.Buffer
+
+	if err := t.Execute(&text, data); err != nil {
+		return "", errors.Wrapf(err, "unable to render the %s message", t.Name())
+	}
+
+	return text.String(), nil
+}
+
+func (b *Bot) welcome() *WelcomeConfig {
+	if b.config == nil {
+		return nil
+	}
+
+	return b.config.Welcome
+}
+
+// resolveFeaturedChannels looks up the IDs of the channels featured in the
+// welcome message.
+func (b *Bot) resolveFeaturedChannels(ids map[string]string) {
+	welcome := b.welcome()
+	if welcome == nil {
+		return
+	}
+
+	b.featuredChannels = nil
+
+	for _, channel := range welcome.FeaturedChannels {
+		id, ok := resolveChannel(channel, ids)
+		if !ok {
+			log.Printf("unable to resolve featured channel #%s, skipping it\n", normalizeChannelName(channel))
+			continue
+		}
+
+		var name string
+		if isChannelName(channel) {
+			name = normalizeChannelName(channel)
+		}
+
+		b.featuredChannels = append(b.featuredChannels, FeaturedChannel{ID: id, Name: name})
+	}
+}
+
+// welcomeData returns the template variables for user, who just joined.
+func (b *Bot) welcomeData(user slack.User) Welcome {
+	displayName := user.Profile.DisplayName
+	if displayName == "" {
+		displayName = user.Name
+	}
+
+	return Welcome{
+		Mention:          "<@" + user.ID + ">",
+		Name:             user.Name,
+		DisplayName:      displayName,
+		RealName:         user.RealName,
+		JoinDate:         b.now(),
+		MemberCount:      b.directory.Members(),
+		FeaturedChannels: b.featuredChannels,
+		Introductions:    b.introductionsChannel,
+	}
+}
@@ -0,0 +1,105 @@
+package mcdowell_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/stretchr/testify/assert"
+	"github.com/willmadison/mcdowell"
+	"github.com/willmadison/mcdowell/internal/slacktest"
+)
+
+const welcomeUsers = `{
+	"ok": true,
+	"members": [
+		{"id": "U0WILL", "name": "willmadison"},
+		{"id": "U0XANGO", "name": "xango"},
+		{"id": "U0GONE", "name": "gone", "deleted": true},
+		{"id": "U0BOT", "name": "mcdowell", "is_bot": true},
+		{"id": "USLACKBOT", "name": "slackbot"}
+	]
+}`
+
+const welcomeChannels = `{
+	"ok": true,
+	"channels": [
+		{"id": "C0000GOLANG", "name": "golang"},
+		{"id": "C000000JOBS", "name": "jobs"}
+	]
+}`
+
+// welcomeResponses are who's in the workspace and which channels it has.
+var welcomeResponses = map[string]string{
+	"users.list":         welcomeUsers,
+	"conversations.list": welcomeChannels,
+}
+
+func TestDefaultWelcomeTemplate(t *testing.T) {
+	m, fake, _ := slacktest.NewBot(t, `{}`, welcomeResponses)
+
+	err := m.OnTeamJoined(&slack.TeamJoinEvent{User: slack.User{ID: "U0SEMMI", Name: "Semmi"}})
+	assert.Nil(t, err)
+
+	var expected bytes.Buffer
+	err = template.Must(template.New("welcome").Parse(mcdowell.DefaultWelcomeTemplate)).Execute(&expected, mcdowell.Welcome{Name: "Semmi"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, expected.String(), fake.Last().Form.Get("text"))
+}
+
+func TestWelcomeTemplateVariables(t *testing.T) {
+	m, fake, clock := slacktest.NewBot(t, `{
+		"welcome": {
+			"template": "Welcome {{.DisplayName}} ({{.RealName}}), member #{{.MemberCount}} since {{.JoinDate.Format \"Jan 2, 2006\"}}! Check out{{range .FeaturedChannels}} {{.}}{{end}}.",
+			"featured_channels": ["#golang", "C000000JOBS", "#missing"]
+		}
+	}`, welcomeResponses)
+	clock.Set(time.Date(1988, time.June, 29, 12, 0, 0, 0, time.UTC))
+
+	err := m.OnTeamJoined(&slack.TeamJoinEvent{
+		User: slack.User{
+			ID:       "U0SEMMI",
+			Name:     "semmi",
+			RealName: "Semmi Joffer",
+			Profile:  slack.UserProfile{DisplayName: "Prince Semmi"},
+		},
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "Welcome Prince Semmi (Semmi Joffer), member #3 since Jun 29, 1988! Check out <#C0000GOLANG> <#C000000JOBS>.", fake.Last().Form.Get("text"))
+
+	err = m.OnTeamJoined(&slack.TeamJoinEvent{User: slack.User{ID: "U0AKEEM", Name: "akeem"}})
+	assert.Nil(t, err)
+
+	assert.Contains(t, fake.Last().Form.Get("text"), "Welcome akeem (), member #4")
+}
+
+func TestWelcomeTemplateFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "welcome.tmpl")
+	err := os.WriteFile(path, []byte("Hey {{.Name}}, welcome to Zamunda."), 0644)
+	assert.Nil(t, err)
+
+	m, fake, _ := slacktest.NewBot(t, `{"welcome": {"template_file": "`+filepath.ToSlash(path)+`"}}`, welcomeResponses)
+
+	err = m.OnTeamJoined(&slack.TeamJoinEvent{User: slack.User{ID: "U0SEMMI", Name: "Semmi"}})
+	assert.Nil(t, err)
+
+	assert.Equal(t, "Hey Semmi, welcome to Zamunda.", fake.Last().Form.Get("text"))
+}
+
+func TestInvalidWelcomeConfigIsRejected(t *testing.T) {
+	for _, config := range []string{
+		`{"welcome": {"template": "Yo {{.Name"}}`,
+		`{"welcome": {"template": "Yo", "template_file": "welcome.tmpl"}}`,
+		`{"welcome": {"template_file": "testdata/does-not-exist.tmpl"}}`,
+	} {
+		_, err := mcdowell.ParseConfig(strings.NewReader(config))
+		assert.NotNil(t, err, config)
+	}
+}
@@ -0,0 +1,336 @@
+package mcdowell
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
+)
+
+// DefaultWinsPrompt is what the bot asks every week unless configured otherwise.
+const DefaultWinsPrompt = `It’s Wins Wednesday! :trophy: What went well for you this week? Big or small, share your wins in this thread so we can celebrate with you.`
+
+// Default schedules for the wins prompt and the newsletter.
+const (
+	DefaultWinsSchedule       = "0 12 * * WED"
+	DefaultNewsletterSchedule = "0 10 1 * *"
+)
+
+// Names of the built in jobs.
+const (
+	winsJob       = "wins"
+	newsletterJob = "newsletter"
+)
+
+const (
+	// winsBucket is where wins shared in reply to a prompt are stored, keyed
+	// by message timestamp.
+	winsBucket = "wins"
+	// winsPromptsBucket is where the prompts wins are shared under are
+	// stored, keyed by message timestamp.
+	winsPromptsBucket = "wins_prompts"
+	// winsRetention is how long wins and prompts are kept.
+	winsRetention = 90 * 24 * time.Hour
+	// maxWinLength is how much of a win makes it into the newsletter.
+	maxWinLength = 280
+)
+
+type (
+	// WinsConfig has the bot ask members to share their wins in a thread in
+	// Channel every week, or on another cron Schedule.
+	WinsConfig struct {
+		Channel  string `json:"channel"`
+		Prompt   string `json:"prompt,omitempty"`
+		Schedule string `json:"schedule,omitempty"`
+	}
+
+	// NewsletterConfig has the bot post a digest of what's been going on in
+	// the community in Channel every month, or on another cron Schedule.
+	NewsletterConfig struct {
+		Channel  string `json:"channel"`
+		Schedule string `json:"schedule,omitempty"`
+	}
+
+	// DigestSection is a part of the newsletter, e.g. the wins shared since
+	// the last one. Sections rendering nothing are left out.
+	DigestSection struct {
+		Title  string
+		Render func(b *Bot, since time.Time) (string, error)
+	}
+
+	// winsPrompt is a prompt the bot posted for wins.
+	winsPrompt struct {
+		Channel string    `json:"channel"`
+		Posted  time.Time `json:"posted"`
+	}
+
+	// win is a member's reply to a wins prompt.
+	win struct {
+		User   string    `json:"user"`
+		Text   string    `json:"text"`
+		Posted time.Time `json:"posted"`
+	}
+)
+
+func (c *WinsConfig) validate() error {
+	if c.Channel == "" {
+		return errors.New("no channel")
+	}
+
+	_, err := ParseSchedule(c.schedule())
+
+	return err
+}
+
+func (c *WinsConfig) schedule() string {
+	if c.Schedule == "" {
+		return DefaultWinsSchedule
+	}
+
+	return c.Schedule
+}
+
+func (c *WinsConfig) prompt() string {
+	if c.Prompt == "" {
+		return DefaultWinsPrompt
+	}
+
+	return c.Prompt
+}
+
+func (c *NewsletterConfig) validate() error {
+	if c.Channel == "" {
+		return errors.New("no channel")
+	}
+
+	_, err := ParseSchedule(c.schedule())
+
+	return err
+}
+
+func (c *NewsletterConfig) schedule() string {
+	if c.Schedule == "" {
+		return DefaultNewsletterSchedule
+	}
+
+	return c.Schedule
+}
+
+func (b *Bot) wins() *WinsConfig {
+	if b.config == nil {
+		return nil
+	}
+
+	return b.config.Wins
+}
+
+func (b *Bot) newsletter() *NewsletterConfig {
+	if b.config == nil {
+		return nil
+	}
+
+	return b.config.Newsletter
+}
+
+// resolveScheduledChannels looks up the IDs of the wins and newsletter channels.
+func (b *Bot) resolveScheduledChannels(ids map[string]string) error {
+	if wins := b.wins(); wins != nil {
+		id, ok := resolveChannel(wins.Channel, ids)
+		if !ok {
+			return errors.Errorf("unable to resolve the wins channel %s", wins.Channel)
+		}
+
+		b.winsChannel = id
+	}
+
+	if newsletter := b.newsletter(); newsletter != nil {
+		id, ok := resolveChannel(newsletter.Channel, ids)
+		if !ok {
+			return errors.Errorf("unable to resolve the newsletter channel %s", newsletter.Channel)
+		}
+
+		b.newsletterChannel = id
+	}
+
+	return nil
+}
+
+// registerBuiltinJobs schedules the wins prompt and the newsletter if they're configured.
+func (b *Bot) registerBuiltinJobs() error {
+	if wins := b.wins(); wins != nil {
+		err := b.RegisterJob(Job{Name: winsJob, Schedule: wins.schedule(), Run: postWinsPrompt})
+		if err != nil {
+			return err
+		}
+
+		err = b.RegisterDigestSection(DigestSection{Title: "Wins", Render: renderWins})
+		if err != nil {
+			return err
+		}
+	}
+
+	if newsletter := b.newsletter(); newsletter != nil {
+		return b.RegisterJob(Job{Name: newsletterJob, Schedule: newsletter.schedule(), Run: postNewsletter})
+	}
+
+	return nil
+}
+
+// RegisterDigestSection adds s to the newsletter, after the sections already added.
+func (b *Bot) RegisterDigestSection(s DigestSection) error {
+	if s.Title == "" {
+		return errors.New("digest section has no title")
+	}
+
+	if s.Render == nil {
+		return errors.Errorf("digest section %q has nothing to render", s.Title)
+	}
+
+	b.jobsMu.Lock()
+	defer b.jobsMu.Unlock()
+
+	b.digestSections = append(b.digestSections, s)
+
+	return nil
+}
+
+// postWinsPrompt asks everyone in the wins channel to share their wins.
+func postWinsPrompt(b *Bot, last time.Time) error {
+	_, ts, err := b.client.PostMessage(b.winsChannel,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(b.wins().prompt(), false),
+	)
+	if err != nil {
+		return errors.Wrap(err, "unable to ask for wins")
+	}
+
+	if err := putJSON(b.store, winsPromptsBucket, ts, winsPrompt{Channel: b.winsChannel, Posted: b.now()}); err != nil {
+		return err
+	}
+
+	return b.pruneWins()
+}
+
+// pruneWins forgets wins and prompts older than winsRetention.
+func (b *Bot) pruneWins() error {
+	cutoff := b.now().Add(-winsRetention)
+
+	for _, bucket := range []string{winsBucket, winsPromptsBucket} {
+		keys, err := b.store.Keys(bucket)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		for _, key := range keys {
+			var posted struct {
+				Posted time.Time `json:"posted"`
+			}
+
+			if err := getJSON(b.store, bucket, key, &posted); err != nil || posted.Posted.Before(cutoff) {
+				if err := b.store.Delete(bucket, key); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordWin keeps replies to the wins prompts for the newsletter.
+func (b *Bot) recordWin(event *slack.MessageEvent) {
+	if event.ThreadTimestamp == "" || event.ThreadTimestamp == event.Timestamp || strings.TrimSpace(event.Text) == "" {
+		return
+	}
+
+	var prompt winsPrompt
+
+	err := getJSON(b.store, winsPromptsBucket, event.ThreadTimestamp, &prompt)
+	if err == ErrNotFound || (err == nil && prompt.Channel != event.Channel) {
+		return
+	}
+
+	if err == nil {
+		err = putJSON(b.store, winsBucket, event.Timestamp, win{User: event.User, Text: event.Text, Posted: b.now()})
+	}
+
+	if err != nil {
+		log.Printf("failed to record a win from %s: %v\n", event.User, err)
+	}
+}
+
+// renderWins lists the wins shared since the last newsletter.
+func renderWins(b *Bot, since time.Time) (string, error) {
+	keys, err := b.store.Keys(winsBucket)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var text strings.Builder
+
+	for _, key := range keys {
+		var w win
+		if err := getJSON(b.store, winsBucket, key, &w); err != nil {
+			return "", err
+		}
+
+		if w.Posted.Before(since) {
+			continue
+		}
+
+		fmt.Fprintf(&text, "• %s: %s\n", b.directory.Addressed(w.User), summarize(w.Text, maxWinLength))
+	}
+
+	return strings.TrimSuffix(text.String(), "\n"), nil
+}
+
+// summarize shortens text to at most max characters, on a single line.
+func summarize(text string, max int) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(text); len(runes) > max {
+		return strings.TrimSpace(string(runes[:max-1])) + "…"
+	}
+
+	return text
+}
+
+// postNewsletter posts every digest section with something to say since the
+// last newsletter, if any do.
+func postNewsletter(b *Bot, last time.Time) error {
+	b.jobsMu.Lock()
+	sections := append([]DigestSection(nil), b.digestSections...)
+	b.jobsMu.Unlock()
+
+	var body strings.Builder
+
+	for _, s := range sections {
+		text, err := s.Render(b, last)
+		if err != nil {
+			return errors.Wrapf(err, "unable to render the %s section", s.Title)
+		}
+
+		if text == "" {
+			continue
+		}
+
+		fmt.Fprintf(&body, "\n\n*%s*\n%s", s.Title, text)
+	}
+
+	if body.Len() == 0 {
+		log.Println("nothing new for the newsletter, skipping it")
+		return nil
+	}
+
+	heading := fmt.Sprintf(":newspaper: *What’s been happening in %s*", b.identity.Team)
+
+	_, _, err := b.client.PostMessage(b.newsletterChannel,
+		slack.MsgOptionAsUser(true),
+		slack.MsgOptionText(heading+body.String(), false),
+	)
+
+	return errors.Wrap(err, "unable to post the newsletter")
+}